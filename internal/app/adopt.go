@@ -0,0 +1,42 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/validator"
+	"api-systemd/internal/service"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Adopt 将已有的单元纳入管理，请求体可省略
+func (s *App) Adopt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Adopt validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	var req service.AdoptRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error(ctx, "Failed to decode adopt request", "error", err)
+		apiResponse(w, -1, "invalid request format", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "Adopt request received", "service", serviceName, "move_binaries", req.MoveBinaries, "restart", req.Restart)
+
+	result, err := s.Service.Adopt(ctx, serviceName, &req)
+	if err != nil {
+		logger.Error(ctx, "Adopt failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to adopt service", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", result)
+}
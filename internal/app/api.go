@@ -1,7 +1,14 @@
 package app
 
 import (
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/unitstore"
+	"api-systemd/internal/pkg/validator"
+	"api-systemd/internal/pkg/workspace"
+	"api-systemd/internal/service"
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -12,10 +19,54 @@ type response struct {
 }
 
 func apiResponse(w http.ResponseWriter, code int, msg string, data any) {
+	apiResponseWithStatus(w, http.StatusOK, code, msg, data)
+}
+
+// apiResponseWithStatus 返回指定 HTTP 状态码的响应
+func apiResponseWithStatus(w http.ResponseWriter, status int, code int, msg string, data any) {
 	resp, err := json.Marshal(&response{Code: code, Msg: msg, Data: data})
 	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte("error marshalling response"))
 		return
 	}
+	w.WriteHeader(status)
 	w.Write(resp)
 }
+
+// errorStatus 根据业务错误返回对应的 HTTP 状态码
+func errorStatus(err error) int {
+	if systemd.IsUnitNotFound(err) || errors.Is(err, systemd.ErrJobNotFound) || errors.Is(err, service.ErrDropInNotFound) ||
+		errors.Is(err, unitstore.ErrVersionNotFound) || errors.Is(err, workspace.ErrReleaseNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, service.ErrUnmanaged) || errors.Is(err, policy.ErrDenied) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, systemd.ErrActionNotApplicable) ||
+		errors.Is(err, service.ErrDropInExists) ||
+		errors.Is(err, service.ErrAlreadyManaged) ||
+		errors.Is(err, service.ErrNotAdoptable) ||
+		errors.Is(err, service.ErrNotTemplate) ||
+		errors.Is(err, workspace.ErrNoPreviousRelease) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, service.ErrInvalidReloadMode) ||
+		errors.Is(err, service.ErrInvalidResources) ||
+		errors.Is(err, systemd.ErrInvalidSignal) ||
+		errors.Is(err, systemd.ErrInvalidKillTarget) ||
+		errors.Is(err, validator.ErrEmptyServiceName) ||
+		errors.Is(err, validator.ErrInvalidServiceName) ||
+		errors.Is(err, validator.ErrInvalidDropInName) ||
+		errors.Is(err, validator.ErrInvalidConfig) ||
+		errors.Is(err, service.ErrEmptyDropIn) ||
+		errors.Is(err, service.ErrInvalidUnitFile) ||
+		errors.Is(err, service.ErrUnsafeUnitValue) ||
+		errors.Is(err, service.ErrInvalidVersionFile) ||
+		errors.Is(err, service.ErrInvalidInstances) ||
+		errors.Is(err, service.ErrSocketTemplate) ||
+		errors.Is(err, service.ErrProtectHome) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/validator"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// GetDependencies 获取服务依赖图，?format=dot 时返回 Graphviz DOT 文本
+func (s *App) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+	format := r.URL.Query().Get("format")
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetDependencies validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+	if format != "" && format != "json" && format != "dot" {
+		logger.Error(ctx, "GetDependencies validation failed", "format", format, "service", serviceName)
+		apiResponse(w, -1, "validation failed", "format must be json or dot")
+		return
+	}
+
+	depth := 0 // 0 表示默认深度
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		parsed, err := strconv.Atoi(depthStr)
+		if err != nil || parsed <= 0 {
+			logger.Error(ctx, "GetDependencies validation failed", "depth", depthStr, "service", serviceName)
+			apiResponse(w, -1, "validation failed", "depth must be a positive integer")
+			return
+		}
+		depth = parsed
+	}
+
+	graph, err := s.Service.GetDependencies(ctx, serviceName, depth)
+	if err != nil {
+		logger.Error(ctx, "GetDependencies failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get dependencies", err.Error())
+		return
+	}
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		_, _ = io.WriteString(w, graph.DOT())
+		return
+	}
+
+	apiResponse(w, 0, "ok", graph)
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/validator"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// dropInRequest drop-in 创建/更新请求
+type dropInRequest struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// getDropInName 获取 drop-in 文件名，省略 .conf 后缀时自动补全
+func getDropInName(name string) string {
+	if name != "" && !strings.HasSuffix(name, ".conf") {
+		name += ".conf"
+	}
+	return name
+}
+
+// ListDropIns 列出服务的 drop-in
+func (s *App) ListDropIns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "ListDropIns validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	dropIns, err := s.Service.ListDropIns(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "ListDropIns failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to list drop-ins", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "drop_ins": dropIns})
+}
+
+// GetDropIn 读取 drop-in 内容
+func (s *App) GetDropIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+	name := getDropInName(chi.URLParam(r, "dropinName"))
+
+	dropIn, err := s.Service.GetDropIn(ctx, serviceName, name)
+	if err != nil {
+		logger.Error(ctx, "GetDropIn failed", "error", err, "service", serviceName, "dropin", name)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get drop-in", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", dropIn)
+}
+
+// CreateDropIn 创建 drop-in
+func (s *App) CreateDropIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	var req dropInRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode drop-in request", "error", err)
+		apiResponse(w, -1, "invalid request format", err.Error())
+		return
+	}
+	name := getDropInName(req.Name)
+
+	logger.Info(ctx, "CreateDropIn request received", "service", serviceName, "dropin", name)
+
+	dropIn, err := s.Service.CreateDropIn(ctx, serviceName, name, req.Content)
+	if err != nil {
+		logger.Error(ctx, "CreateDropIn failed", "error", err, "service", serviceName, "dropin", name)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to create drop-in", err.Error())
+		return
+	}
+
+	apiResponseWithStatus(w, http.StatusCreated, 0, "ok", dropIn)
+}
+
+// UpdateDropIn 更新 drop-in
+func (s *App) UpdateDropIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+	name := getDropInName(chi.URLParam(r, "dropinName"))
+
+	var req dropInRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode drop-in request", "error", err)
+		apiResponse(w, -1, "invalid request format", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "UpdateDropIn request received", "service", serviceName, "dropin", name)
+
+	dropIn, err := s.Service.UpdateDropIn(ctx, serviceName, name, req.Content)
+	if err != nil {
+		logger.Error(ctx, "UpdateDropIn failed", "error", err, "service", serviceName, "dropin", name)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to update drop-in", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", dropIn)
+}
+
+// DeleteDropIn 删除 drop-in
+func (s *App) DeleteDropIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+	name := getDropInName(chi.URLParam(r, "dropinName"))
+
+	logger.Info(ctx, "DeleteDropIn request received", "service", serviceName, "dropin", name)
+
+	if err := s.Service.DeleteDropIn(ctx, serviceName, name); err != nil {
+		logger.Error(ctx, "DeleteDropIn failed", "error", err, "service", serviceName, "dropin", name)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to delete drop-in", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]string{"service": serviceName, "drop_in": name})
+}
+
+// GetEffectiveUnit 获取基础单元及 drop-in 合并后的视图
+func (s *App) GetEffectiveUnit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetEffectiveUnit validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	unit, err := s.Service.GetEffectiveUnit(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "GetEffectiveUnit failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get unit", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", unit)
+}
+
+// GetServiceConfig 获取从单元文件解析出的服务配置
+func (s *App) GetServiceConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetServiceConfig validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	config, err := s.Service.GetServiceConfig(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "GetServiceConfig failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get service config", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", config)
+}
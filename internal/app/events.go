@@ -0,0 +1,186 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/events"
+	"api-systemd/internal/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// eventHeartbeatInterval 事件流心跳间隔，避免空闲连接被代理断开
+const eventHeartbeatInterval = 15 * time.Second
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// 已通过 Bearer Token 认证，允许跨域连接
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// getEventFilter 解析事件过滤参数（?service=a,b&type=unit_state,deploy）
+func getEventFilter(r *http.Request) events.Filter {
+	filter := events.Filter{
+		Services: splitQuery(r.URL.Query().Get("service")),
+	}
+	for _, t := range splitQuery(r.URL.Query().Get("type")) {
+		filter.Types = append(filter.Types, events.EventType(t))
+	}
+	return filter
+}
+
+// getLastEventID 获取续传的事件ID，优先使用 Last-Event-ID 请求头
+func getLastEventID(r *http.Request) uint64 {
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	id, _ := strconv.ParseUint(lastID, 10, 64)
+	return id
+}
+
+// splitQuery 拆分逗号分隔的查询参数
+func splitQuery(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// StreamEvents 以 Server-Sent Events 推送事件
+func (s *App) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filter := getEventFilter(r)
+	lastID := getLastEventID(r)
+
+	rc := http.NewResponseController(w)
+	// 事件流是长连接，取消服务器的读写超时
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		logger.Warn(ctx, "Failed to clear read deadline for event stream", "error", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn(ctx, "Failed to clear write deadline for event stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	replay, ch, cancel := s.Events.Subscribe(filter, lastID)
+	defer cancel()
+
+	logger.Info(ctx, "Event stream opened", "transport", "sse", "services", filter.Services, "types", filter.Types, "last_event_id", lastID)
+
+	for _, e := range replay {
+		if err := writeSSE(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		logger.Error(ctx, "Event stream does not support flushing", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(ctx, "Event stream closed", "transport", "sse")
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e := <-ch:
+			if err := writeSSE(w, e); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSE 写入一条 SSE 事件
+func writeSSE(w http.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// StreamEventsWS 以 WebSocket 推送事件
+func (s *App) StreamEventsWS(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filter := getEventFilter(r)
+	lastID := getLastEventID(r)
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 已经向客户端写入了错误响应
+		logger.Error(ctx, "Failed to upgrade event stream to websocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// 连接已被接管，取消服务器设置的读写超时
+	if err := conn.NetConn().SetDeadline(time.Time{}); err != nil {
+		logger.Warn(ctx, "Failed to clear deadline for websocket event stream", "error", err)
+	}
+
+	replay, ch, cancel := s.Events.Subscribe(filter, lastID)
+	defer cancel()
+
+	logger.Info(ctx, "Event stream opened", "transport", "websocket", "services", filter.Services, "types", filter.Types, "last_event_id", lastID)
+
+	// 读取客户端消息以处理 ping/close 控制帧，连接关闭时结束推送
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, e := range replay {
+		if err := conn.WriteJSON(e); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			logger.Info(ctx, "Event stream closed", "transport", "websocket")
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		case e := <-ch:
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -2,14 +2,15 @@ package app
 
 import (
 	"api-systemd/internal/pkg/config"
+	"api-systemd/internal/pkg/events"
 	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
 	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/unitpath"
 	"api-systemd/internal/pkg/validator"
 	"api-systemd/internal/service"
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
@@ -17,12 +18,18 @@ import (
 )
 
 type App struct {
-	Service service.Service
+	Service    service.Service
+	SystemdMgr *systemd.Manager
+	Paths      *unitpath.Resolver
+	Events     *events.Broker
 }
 
-func New(cfg *config.Config) *App {
+func New(cfg *config.Config, systemdMgr *systemd.Manager, paths *unitpath.Resolver, broker *events.Broker, pol *policy.Policy) *App {
 	return &App{
-		Service: service.NewService(cfg.Workspace.WorkDir),
+		Service:    service.NewService(cfg.Workspace.WorkDir, systemdMgr, paths, broker, cfg.Systemd.AllowUnmanaged, pol, cfg.Workspace.UnitHistory, cfg.Workspace.Releases),
+		SystemdMgr: systemdMgr,
+		Paths:      paths,
+		Events:     broker,
 	}
 }
 
@@ -36,25 +43,63 @@ func getServiceName(r *http.Request) string {
 	return r.URL.Query().Get("service")
 }
 
+// getJobOptions 解析任务等待参数（?wait=false&timeout=30s）
+func getJobOptions(r *http.Request) service.JobOptions {
+	opts := service.JobOptions{Wait: true}
+
+	if waitStr := r.URL.Query().Get("wait"); waitStr != "" {
+		if wait, err := strconv.ParseBool(waitStr); err == nil {
+			opts.Wait = wait
+		}
+	}
+
+	if timeoutStr := r.URL.Query().Get("timeout"); timeoutStr != "" {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil && timeout > 0 {
+			opts.Timeout = timeout
+		}
+	}
+
+	return opts
+}
+
+// jobFailure 构造任务失败时的响应数据
+func jobFailure(err error, job *systemd.Job) any {
+	if job == nil {
+		return err.Error()
+	}
+	return map[string]any{
+		"error": err.Error(),
+		"job":   job,
+	}
+}
+
+// jobStatus 根据任务状态返回服务状态描述
+func jobStatus(job *systemd.Job, finished string) string {
+	if job.State == systemd.JobStateRunning {
+		return "pending"
+	}
+	return finished
+}
+
 // 启动 Systemd 服务
 func (s *App) StartService(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	service := r.URL.Query().Get("service")
+	service := getServiceName(r)
 
-	if err := validator.ValidateServiceName(service); err != nil {
+	if err := validator.ValidateInstanceName(service); err != nil {
 		logger.Error(ctx, "StartService validation failed", "error", err, "service", service)
 		apiResponse(w, -1, "validation failed", err.Error())
 		return
 	}
 
-	err := s.Service.Start(ctx, service)
+	job, err := s.Service.Start(ctx, service, getJobOptions(r))
 	if err != nil {
 		logger.Error(ctx, "StartService failed", "error", err, "service", service)
-		apiResponse(w, -1, "failed", err.Error())
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed", jobFailure(err, job))
 		return
 	}
 
-	apiResponse(w, 0, "ok", map[string]string{"service": service, "status": "started"})
+	apiResponse(w, 0, "ok", map[string]any{"service": service, "status": jobStatus(job, "started"), "job": job})
 }
 
 // CreateConfig 创建配置
@@ -85,22 +130,9 @@ func (s *App) CreateConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filePath := fmt.Sprintf("/etc/systemd/system/%s.service", configRequest.Service)
-	logger.Info(ctx, "Creating config file", "service", configRequest.Service, "file", filePath)
-
-	err := os.WriteFile(filePath, []byte(configRequest.Config), 0644)
-	if err != nil {
-		logger.Error(ctx, "Failed to write config file", "error", err, "service", configRequest.Service, "file", filePath)
-		apiResponse(w, -1, "failed", err.Error())
-		return
-	}
-
-	// 重新加载 Systemd 配置
-	logger.Info(ctx, "Reloading systemd daemon")
-	err = systemd.ReloadDaemon()
+	filePath, err := s.Service.WriteUnitFile(ctx, configRequest.Service, configRequest.Config)
 	if err != nil {
-		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
-		apiResponse(w, -1, "failed", err.Error())
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed", err.Error())
 		return
 	}
 
@@ -121,22 +153,9 @@ func (s *App) DeleteConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filePath := fmt.Sprintf("/etc/systemd/system/%s.service", serviceName)
-	logger.Info(ctx, "Deleting config file", "service", serviceName, "file", filePath)
-
-	err := os.Remove(filePath)
+	filePath, err := s.Service.RemoveUnitFile(ctx, serviceName)
 	if err != nil {
-		logger.Error(ctx, "Failed to delete config file", "error", err, "service", serviceName, "file", filePath)
-		apiResponse(w, -1, "failed", err.Error())
-		return
-	}
-
-	// 重新加载 Systemd 配置
-	logger.Info(ctx, "Reloading systemd daemon")
-	err = systemd.ReloadDaemon()
-	if err != nil {
-		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
-		apiResponse(w, -1, "failed", err.Error())
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed", err.Error())
 		return
 	}
 
@@ -152,7 +171,7 @@ func (s *App) GetStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	serviceName := getServiceName(r)
 
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "GetStatus validation failed", "error", err, "service", serviceName)
 		apiResponse(w, -1, "validation failed", err.Error())
 		return
@@ -160,10 +179,34 @@ func (s *App) GetStatus(w http.ResponseWriter, r *http.Request) {
 
 	logger.Info(ctx, "GetStatus request received", "service", serviceName)
 
+	// 模板服务返回所有实例的汇总状态
+	if s.Service.IsTemplate(serviceName) {
+		status, err := s.Service.GetTemplateStatus(ctx, serviceName)
+		if err != nil {
+			logger.Error(ctx, "GetTemplateStatus failed", "error", err, "service", serviceName)
+			apiResponseWithStatus(w, errorStatus(err), -1, "failed to get status", err.Error())
+			return
+		}
+		apiResponse(w, 0, "ok", status)
+		return
+	}
+
+	// 套接字激活服务附带套接字状态
+	if s.Service.HasSocket(serviceName) {
+		status, err := s.Service.GetSocketStatus(ctx, serviceName)
+		if err != nil {
+			logger.Error(ctx, "GetSocketStatus failed", "error", err, "service", serviceName)
+			apiResponseWithStatus(w, errorStatus(err), -1, "failed to get status", err.Error())
+			return
+		}
+		apiResponse(w, 0, "ok", status)
+		return
+	}
+
 	status, err := s.Service.GetStatus(ctx, serviceName)
 	if err != nil {
 		logger.Error(ctx, "GetStatus failed", "error", err, "service", serviceName)
-		apiResponse(w, -1, "failed to get status", err.Error())
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get status", err.Error())
 		return
 	}
 
@@ -176,7 +219,7 @@ func (s *App) Stop(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	serviceName := getServiceName(r)
 
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "Stop validation failed", "error", err, "service", serviceName)
 		apiResponse(w, -1, "validation failed", err.Error())
 		return
@@ -184,14 +227,15 @@ func (s *App) Stop(w http.ResponseWriter, r *http.Request) {
 
 	logger.Info(ctx, "Stop request received", "service", serviceName)
 
-	if err := s.Service.Stop(ctx, serviceName); err != nil {
+	job, err := s.Service.Stop(ctx, serviceName, getJobOptions(r))
+	if err != nil {
 		logger.Error(ctx, "Stop failed", "error", err, "service", serviceName)
-		apiResponse(w, -1, "stop failed", err.Error())
+		apiResponseWithStatus(w, errorStatus(err), -1, "stop failed", jobFailure(err, job))
 		return
 	}
 
 	logger.Info(ctx, "Stop completed successfully", "service", serviceName)
-	apiResponse(w, 0, "ok", map[string]string{"service": serviceName, "status": "stopped"})
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "status": jobStatus(job, "stopped"), "job": job})
 }
 
 // Remove 移除服务接口
@@ -209,7 +253,7 @@ func (s *App) Remove(w http.ResponseWriter, r *http.Request) {
 
 	if err := s.Service.Remove(ctx, serviceName); err != nil {
 		logger.Error(ctx, "Remove failed", "error", err, "service", serviceName)
-		apiResponse(w, -1, "remove failed", err.Error())
+		apiResponseWithStatus(w, errorStatus(err), -1, "remove failed", err.Error())
 		return
 	}
 
@@ -222,7 +266,7 @@ func (s *App) Restart(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	serviceName := getServiceName(r)
 
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "Restart validation failed", "error", err, "service", serviceName)
 		apiResponse(w, -1, "validation failed", err.Error())
 		return
@@ -230,14 +274,173 @@ func (s *App) Restart(w http.ResponseWriter, r *http.Request) {
 
 	logger.Info(ctx, "Restart request received", "service", serviceName)
 
-	if err := s.Service.Restart(ctx, serviceName); err != nil {
+	job, err := s.Service.Restart(ctx, serviceName, getJobOptions(r))
+	if err != nil {
 		logger.Error(ctx, "Restart failed", "error", err, "service", serviceName)
-		apiResponse(w, -1, "restart failed", err.Error())
+		apiResponseWithStatus(w, errorStatus(err), -1, "restart failed", jobFailure(err, job))
 		return
 	}
 
 	logger.Info(ctx, "Restart completed successfully", "service", serviceName)
-	apiResponse(w, 0, "ok", map[string]string{"service": serviceName, "status": "restarted"})
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "status": jobStatus(job, "restarted"), "job": job})
+}
+
+// Kill 向服务进程发送信号接口 (?signal=SIGTERM&target=all)
+func (s *App) Kill(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
+		logger.Error(ctx, "Kill validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	signal := r.URL.Query().Get("signal")
+	if signal == "" {
+		signal = "SIGTERM"
+	}
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		target = string(systemd.KillAll)
+	}
+
+	logger.Info(ctx, "Kill request received", "service", serviceName, "signal", signal, "target", target)
+
+	if err := s.Service.Kill(ctx, serviceName, signal, target); err != nil {
+		logger.Error(ctx, "Kill failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "kill failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "Kill completed successfully", "service", serviceName)
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "status": "signaled", "signal": signal, "target": target})
+}
+
+// ResetFailed 清除服务失败状态接口
+func (s *App) ResetFailed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
+		logger.Error(ctx, "ResetFailed validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "ResetFailed request received", "service", serviceName)
+
+	if err := s.Service.ResetFailed(ctx, serviceName); err != nil {
+		logger.Error(ctx, "ResetFailed failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "reset-failed failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "ResetFailed completed successfully", "service", serviceName)
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "status": "reset"})
+}
+
+// Mask 屏蔽服务接口
+func (s *App) Mask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Mask validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "Mask request received", "service", serviceName)
+
+	if err := s.Service.Mask(ctx, serviceName); err != nil {
+		logger.Error(ctx, "Mask failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "mask failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "Mask completed successfully", "service", serviceName)
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "status": "masked"})
+}
+
+// Unmask 取消服务屏蔽接口
+func (s *App) Unmask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Unmask validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "Unmask request received", "service", serviceName)
+
+	if err := s.Service.Unmask(ctx, serviceName); err != nil {
+		logger.Error(ctx, "Unmask failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "unmask failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "Unmask completed successfully", "service", serviceName)
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "status": "unmasked"})
+}
+
+// UpdateResources 在线调整服务资源限制接口
+func (s *App) UpdateResources(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "UpdateResources validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	var req service.ResourceRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode resources request", "error", err)
+		apiResponse(w, -1, "invalid request format", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "UpdateResources request received", "service", serviceName, "persistent", req.Persistent)
+
+	unit, err := s.Service.UpdateResources(ctx, serviceName, &req)
+	if err != nil {
+		logger.Error(ctx, "UpdateResources failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to update resources", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "UpdateResources completed successfully", "service", serviceName)
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "persistent": req.Persistent, "status": unit})
+}
+
+// Reload 重新加载服务接口 (?mode=reload-or-restart)
+func (s *App) Reload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
+		logger.Error(ctx, "Reload validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	logger.Info(ctx, "Reload request received", "service", serviceName, "mode", mode)
+
+	job, err := s.Service.Reload(ctx, serviceName, mode, getJobOptions(r))
+	if err != nil {
+		logger.Error(ctx, "Reload failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "reload failed", jobFailure(err, job))
+		return
+	}
+
+	logger.Info(ctx, "Reload completed successfully", "service", serviceName)
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "status": jobStatus(job, "reloaded"), "job": job})
 }
 
 // GetLogs 获取服务日志接口
@@ -246,7 +449,7 @@ func (s *App) GetLogs(w http.ResponseWriter, r *http.Request) {
 	serviceName := getServiceName(r)
 	linesStr := r.URL.Query().Get("lines")
 
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "GetServiceLogs validation failed", "error", err, "service", serviceName)
 		apiResponse(w, -1, "validation failed", err.Error())
 		return
@@ -276,6 +479,27 @@ func (s *App) GetLogs(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetJob 获取任务状态接口
+func (s *App) GetJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID := chi.URLParam(r, "jobID")
+
+	if jobID == "" {
+		logger.Error(ctx, "GetJob validation failed", "job", jobID)
+		apiResponse(w, -1, "validation failed", "job id cannot be empty")
+		return
+	}
+
+	job, err := s.Service.GetJob(ctx, jobID)
+	if err != nil {
+		logger.Error(ctx, "GetJob failed", "error", err, "job", jobID)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get job", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", job)
+}
+
 // HealthCheck 健康检查接口
 func (s *App) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -291,12 +515,18 @@ func (s *App) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 简单检查systemd是否可用
-	if err := systemd.CheckSystemdAvailable(); err != nil {
+	if err := s.SystemdMgr.CheckSystemdAvailable(ctx); err != nil {
 		health["status"] = "degraded"
 		health["services"].(map[string]string)["systemd"] = "unavailable"
 		logger.Warn(ctx, "Systemd not available", "error", err)
 	}
 
+	// D-Bus 连接状态
+	health["dbus"] = s.SystemdMgr.Health()
+
+	// 单元目录，runtime 为 true 时部署的单元在系统重启后消失
+	health["unit_dir"] = map[string]any{"path": s.Paths.Dir(), "runtime": s.Paths.Runtime()}
+
 	logger.Debug(ctx, "Health check performed")
 	apiResponse(w, 0, "ok", health)
 }
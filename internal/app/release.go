@@ -0,0 +1,52 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// rollbackRequest 回滚请求，release 为空时回滚到当前版本之前的版本
+type rollbackRequest struct {
+	Release string `json:"release"`
+}
+
+// ListReleases 列出服务已部署的版本
+func (s *App) ListReleases(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	releases, err := s.Service.ListReleases(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "ListReleases failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to list releases", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "releases": releases})
+}
+
+// Rollback 切换到之前的版本并重启服务（?wait=false&timeout=30s），请求体可以省略
+func (s *App) Rollback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	var req rollbackRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error(ctx, "Failed to decode rollback request", "error", err)
+		apiResponse(w, -1, "invalid request format", err.Error())
+		return
+	}
+
+	result, err := s.Service.Rollback(ctx, serviceName, req.Release, getJobOptions(r))
+	if err != nil {
+		logger.Error(ctx, "Rollback failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to roll back service", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", result)
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/logs"
+	"api-systemd/internal/pkg/validator"
+	"api-systemd/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// runStreamMessage 流式运行输出的一行 NDJSON 消息
+type runStreamMessage struct {
+	Type   string             `json:"type"` // log, result, error
+	Log    *logs.LogEntry     `json:"log,omitempty"`
+	Result *service.RunResult `json:"result,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// RunCommand 以临时单元运行一次性命令（如数据库迁移）
+// 默认以 NDJSON 流式返回命令输出，最后一行为退出状态；?stream=false 时等待结束后一次性返回
+func (s *App) RunCommand(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	var runRequest struct {
+		service.RunRequest
+		Timeout string `json:"timeout,omitempty"` // 最长运行时间，如 "10m"
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&runRequest); err != nil {
+		logger.Error(ctx, "Failed to decode run request", "error", err)
+		apiResponse(w, -1, "invalid request format", err.Error())
+		return
+	}
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "RunCommand validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+	if len(runRequest.Command) == 0 || runRequest.Command[0] == "" {
+		apiResponse(w, -1, "validation failed", service.ErrEmptyCommand.Error())
+		return
+	}
+	if runRequest.Timeout != "" {
+		timeout, err := time.ParseDuration(runRequest.Timeout)
+		if err != nil || timeout <= 0 {
+			apiResponse(w, -1, "validation failed", "invalid timeout: "+runRequest.Timeout)
+			return
+		}
+		runRequest.RunRequest.Timeout = timeout
+	}
+
+	stream := true
+	if streamStr := r.URL.Query().Get("stream"); streamStr != "" {
+		if v, err := strconv.ParseBool(streamStr); err == nil {
+			stream = v
+		}
+	}
+
+	if !stream {
+		var mu sync.Mutex
+		var output []logs.LogEntry
+		result, err := s.Service.Run(ctx, serviceName, &runRequest.RunRequest, func(entry logs.LogEntry) {
+			mu.Lock()
+			output = append(output, entry)
+			mu.Unlock()
+		})
+		if err != nil {
+			logger.Error(ctx, "RunCommand failed", "error", err, "service", serviceName)
+			apiResponseWithStatus(w, errorStatus(err), -1, "failed", err.Error())
+			return
+		}
+		apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "result": result, "logs": output})
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// 命令可能长时间运行，取消服务器的读写超时
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		logger.Warn(ctx, "Failed to clear read deadline for run stream", "error", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn(ctx, "Failed to clear write deadline for run stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	writeMessage := func(msg runStreamMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(msg); err == nil {
+			rc.Flush()
+		}
+	}
+
+	result, err := s.Service.Run(ctx, serviceName, &runRequest.RunRequest, func(entry logs.LogEntry) {
+		writeMessage(runStreamMessage{Type: "log", Log: &entry})
+	})
+	if err != nil {
+		logger.Error(ctx, "RunCommand failed", "error", err, "service", serviceName)
+		writeMessage(runStreamMessage{Type: "error", Error: err.Error()})
+		return
+	}
+	writeMessage(runStreamMessage{Type: "result", Result: result})
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/validator"
+	"encoding/json"
+	"net/http"
+)
+
+// scaleRequest 扩缩容请求
+type scaleRequest struct {
+	Instances *int `json:"instances"`
+}
+
+// Scale 调整模板服务的实例数（?wait=false&timeout=30s）
+func (s *App) Scale(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Scale validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	var req scaleRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode scale request", "error", err)
+		apiResponse(w, -1, "invalid request format", err.Error())
+		return
+	}
+	if req.Instances == nil {
+		apiResponse(w, -1, "validation failed", "instances is required")
+		return
+	}
+
+	result, err := s.Service.Scale(ctx, serviceName, *req.Instances, getJobOptions(r))
+	if err != nil {
+		logger.Error(ctx, "Scale failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to scale service", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", result)
+}
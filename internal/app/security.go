@@ -0,0 +1,28 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/validator"
+	"net/http"
+)
+
+// GetSecurity 获取服务的安全暴露度评估
+func (s *App) GetSecurity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetSecurity validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	report, err := s.Service.GetSecurity(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "GetSecurity failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get security report", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", report)
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/validator"
+	"api-systemd/internal/service"
+	"encoding/json"
+	"net/http"
+)
+
+// DeployTimer 部署定时任务接口
+func (s *App) DeployTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var params service.DeployTimerRequest
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Error(ctx, "Failed to decode timer deploy request", "error", err)
+		apiResponse(w, -1, "invalid request format", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "DeployTimer request received", "service", params.Service, "url", params.PackageURL)
+
+	if err := s.Service.DeployTimer(ctx, &params); err != nil {
+		logger.Error(ctx, "DeployTimer failed", "error", err, "service", params.Service)
+		apiResponse(w, -1, "deploy failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "DeployTimer completed successfully", "service", params.Service)
+	apiResponse(w, 0, "ok", map[string]string{
+		"service": params.Service,
+		"timer":   params.Service + ".timer",
+		"status":  "deployed",
+	})
+}
+
+// ListTimers 获取定时任务列表接口
+func (s *App) ListTimers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger.Info(ctx, "Listing timers")
+
+	timers, err := s.Service.ListTimers(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to list timers", "error", err)
+		apiResponse(w, -1, "failed to list timers", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]interface{}{
+		"timers": timers,
+		"count":  len(timers),
+	})
+}
+
+// GetTimer 获取定时任务状态接口
+func (s *App) GetTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetTimer validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	timer, err := s.Service.GetTimer(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "GetTimer failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get timer", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", timer)
+}
+
+// TriggerTimer 立即运行定时任务接口
+func (s *App) TriggerTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "TriggerTimer validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "TriggerTimer request received", "service", serviceName)
+
+	job, err := s.Service.TriggerTimer(ctx, serviceName, getJobOptions(r))
+	if err != nil {
+		logger.Error(ctx, "TriggerTimer failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "trigger failed", jobFailure(err, job))
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "status": jobStatus(job, "completed"), "job": job})
+}
+
+// RemoveTimer 移除定时任务接口
+func (s *App) RemoveTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "RemoveTimer validation failed", "error", err, "service", serviceName)
+		apiResponse(w, -1, "validation failed", err.Error())
+		return
+	}
+
+	logger.Info(ctx, "RemoveTimer request received", "service", serviceName)
+
+	if err := s.Service.RemoveTimer(ctx, serviceName); err != nil {
+		logger.Error(ctx, "RemoveTimer failed", "error", err, "service", serviceName)
+		apiResponseWithStatus(w, errorStatus(err), -1, "remove failed", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]string{"service": serviceName, "status": "removed"})
+}
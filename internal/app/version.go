@@ -0,0 +1,103 @@
+package app
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// parseVersionID 解析版本号，必须为正整数
+func parseVersionID(value string) (int, bool) {
+	id, err := strconv.Atoi(value)
+	return id, err == nil && id > 0
+}
+
+// ListUnitVersions 列出单元文件的历史版本（?file= 指定 timer 或 drop-in）
+func (s *App) ListUnitVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+	file := r.URL.Query().Get("file")
+
+	versions, err := s.Service.ListUnitVersions(ctx, serviceName, file)
+	if err != nil {
+		logger.Error(ctx, "ListUnitVersions failed", "error", err, "service", serviceName, "file", file)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to list versions", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "versions": versions})
+}
+
+// GetUnitVersion 读取单元文件历史版本的内容
+func (s *App) GetUnitVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+	file := r.URL.Query().Get("file")
+
+	id, ok := parseVersionID(chi.URLParam(r, "versionID"))
+	if !ok {
+		apiResponse(w, -1, "validation failed", "version id must be a positive integer")
+		return
+	}
+
+	version, err := s.Service.GetUnitVersion(ctx, serviceName, file, id)
+	if err != nil {
+		logger.Error(ctx, "GetUnitVersion failed", "error", err, "service", serviceName, "file", file, "version", id)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to get version", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", version)
+}
+
+// DiffUnitVersions 比较单元文件的两个历史版本（?from=1&to=2），省略 to 时与当前文件比较
+func (s *App) DiffUnitVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+	file := r.URL.Query().Get("file")
+
+	from, ok := parseVersionID(r.URL.Query().Get("from"))
+	if !ok {
+		apiResponse(w, -1, "validation failed", "from must be a positive integer")
+		return
+	}
+	to := 0 // 0 表示当前文件
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		if to, ok = parseVersionID(toStr); !ok {
+			apiResponse(w, -1, "validation failed", "to must be a positive integer")
+			return
+		}
+	}
+
+	diff, err := s.Service.DiffUnitVersions(ctx, serviceName, file, from, to)
+	if err != nil {
+		logger.Error(ctx, "DiffUnitVersions failed", "error", err, "service", serviceName, "file", file)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to diff versions", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "from": from, "to": to, "diff": diff})
+}
+
+// RestoreUnitVersion 将单元文件恢复到历史版本
+func (s *App) RestoreUnitVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := getServiceName(r)
+	file := r.URL.Query().Get("file")
+
+	id, ok := parseVersionID(chi.URLParam(r, "versionID"))
+	if !ok {
+		apiResponse(w, -1, "validation failed", "version id must be a positive integer")
+		return
+	}
+
+	if err := s.Service.RestoreUnitVersion(ctx, serviceName, file, id); err != nil {
+		logger.Error(ctx, "RestoreUnitVersion failed", "error", err, "service", serviceName, "file", file, "version", id)
+		apiResponseWithStatus(w, errorStatus(err), -1, "failed to restore version", err.Error())
+		return
+	}
+
+	apiResponse(w, 0, "ok", map[string]any{"service": serviceName, "restored": id})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 )
@@ -15,6 +16,7 @@ type Config struct {
 	Security  SecurityConfig  `json:"security"`
 	Logging   LoggingConfig   `json:"logging"`
 	Workspace WorkspaceConfig `json:"workspace"`
+	Systemd   SystemdConfig   `json:"systemd"`
 }
 
 // ServerConfig 服务器配置
@@ -43,7 +45,17 @@ type LoggingConfig struct {
 
 // WorkspaceConfig 工作空间配置
 type WorkspaceConfig struct {
-	WorkDir string `json:"work_dir"` // 工作目录根路径
+	WorkDir     string `json:"work_dir"`     // 工作目录根路径
+	UnitHistory int    `json:"unit_history"` // 每个单元文件保留的历史版本数量
+	Releases    int    `json:"releases"`     // 每个服务保留的部署版本数量
+}
+
+// SystemdConfig systemd 管理器配置
+type SystemdConfig struct {
+	UserMode       bool   `json:"user_mode"`       // 管理当前用户的 systemd --user 实例，无需 root
+	AllowUnmanaged bool   `json:"allow_unmanaged"` // 允许对非本系统部署或接管的单元执行变更操作
+	PolicyFile     string `json:"policy_file"`     // 单元操作策略文件（JSON），为空时只保护默认的关键单元
+	UnitDir        string `json:"unit_dir"`        // 写入单元文件的目录，为空时按运行模式使用默认目录
 }
 
 // Load 加载配置
@@ -59,6 +71,15 @@ func Load() *Config {
 		fmt.Printf("   建议在生产环境中设置固定的 API_KEY 环境变量\n\n")
 	}
 
+	// 用户模式下默认使用用户目录作为工作空间
+	userMode := getBoolEnv("SYSTEMD_USER_MODE", false)
+	defaultWorkDir := "/opt/api-systemd"
+	if userMode {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			defaultWorkDir = filepath.Join(homeDir, ".local", "share", "api-systemd")
+		}
+	}
+
 	return &Config{
 		Server: ServerConfig{
 			Port:            getEnv("SERVER_PORT", ":8080"),
@@ -79,7 +100,15 @@ func Load() *Config {
 			OutputFile: getEnv("LOG_OUTPUT_FILE", ""),
 		},
 		Workspace: WorkspaceConfig{
-			WorkDir: getEnv("WORK_DIR", "/opt/api-systemd"),
+			WorkDir:     getEnv("WORK_DIR", defaultWorkDir),
+			UnitHistory: getIntEnv("UNIT_HISTORY_LIMIT", 10),
+			Releases:    getIntEnv("RELEASE_LIMIT", 5),
+		},
+		Systemd: SystemdConfig{
+			UserMode:       userMode,
+			AllowUnmanaged: getBoolEnv("SYSTEMD_ALLOW_UNMANAGED", false),
+			PolicyFile:     getEnv("SYSTEMD_POLICY_FILE", ""),
+			UnitDir:        getEnv("SYSTEMD_UNIT_DIR", ""),
 		},
 	}
 }
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType 事件类型
+type EventType string
+
+const (
+	EventUnitState EventType = "unit_state" // 单元状态变化
+	EventDeploy    EventType = "deploy"     // 部署事件
+	EventHook      EventType = "hook"       // 钩子执行结果
+	EventAdopt     EventType = "adopt"      // 接管已有服务
+	EventAudit     EventType = "audit"      // 被策略拒绝的操作
+	EventScale     EventType = "scale"      // 模板服务扩缩容
+	EventRollback  EventType = "rollback"   // 回滚到之前的版本
+)
+
+// DefaultBufferSize 默认保留的历史事件数量，用于断线续传
+const DefaultBufferSize = 1024
+
+// subscriberQueueSize 每个订阅者的事件队列长度
+const subscriberQueueSize = 256
+
+// Event 事件
+type Event struct {
+	ID        uint64                 `json:"id"`
+	Type      EventType              `json:"type"`
+	Service   string                 `json:"service"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Filter 事件过滤条件，为空表示不过滤
+type Filter struct {
+	Services []string
+	Types    []EventType
+}
+
+// Match 判断事件是否满足过滤条件
+func (f Filter) Match(e Event) bool {
+	if len(f.Services) > 0 && !contains(f.Services, e.Service) {
+		return false
+	}
+	if len(f.Types) > 0 && !contains(f.Types, e.Type) {
+		return false
+	}
+	return true
+}
+
+func contains[T comparable](list []T, v T) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// subscriber 事件订阅者
+type subscriber struct {
+	filter Filter
+	ch     chan Event
+}
+
+// Broker 事件代理，负责发布、订阅和历史事件回放
+type Broker struct {
+	mu     sync.Mutex
+	nextID uint64
+	buffer []Event
+	size   int
+	subs   map[*subscriber]struct{}
+}
+
+// NewBroker 创建事件代理
+func NewBroker(size int) *Broker {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Broker{
+		nextID: 1,
+		size:   size,
+		subs:   make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish 发布事件
+// 订阅者队列已满时丢弃该订阅者的事件，不会阻塞发布方
+func (b *Broker) Publish(eventType EventType, service string, data map[string]interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := Event{
+		ID:        b.nextID,
+		Type:      eventType,
+		Service:   service,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+	b.nextID++
+
+	b.buffer = append(b.buffer, e)
+	if len(b.buffer) > b.size {
+		b.buffer = b.buffer[len(b.buffer)-b.size:]
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+		}
+	}
+
+	return e
+}
+
+// Subscribe 订阅事件
+// lastID 大于0时，先返回缓冲区中 ID 大于 lastID 的历史事件用于续传
+func (b *Broker) Subscribe(filter Filter, lastID uint64) ([]Event, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if lastID > 0 {
+		for _, e := range b.buffer {
+			if e.ID > lastID && filter.Match(e) {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	sub := &subscriber{
+		filter: filter,
+		ch:     make(chan Event, subscriberQueueSize),
+	}
+	b.subs[sub] = struct{}{}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+		})
+	}
+
+	return replay, sub.ch, cancel
+}
@@ -15,6 +15,8 @@ const (
 	HookPostStop    HookType = "post_stop"    // 停止后
 	HookPreRestart  HookType = "pre_restart"  // 重启前
 	HookPostRestart HookType = "post_restart" // 重启后
+	HookPreReload   HookType = "pre_reload"   // 重新加载前
+	HookPostReload  HookType = "post_reload"  // 重新加载后
 	HookOnFailure   HookType = "on_failure"   // 失败时
 	HookOnSuccess   HookType = "on_success"   // 成功时
 )
@@ -74,19 +76,43 @@ type ServiceConfig struct {
 	Description      string            `json:"description"`
 	WorkingDirectory string            `json:"working_directory"`
 	ExecStart        string            `json:"exec_start"`
+	ExecReload       string            `json:"exec_reload,omitempty"`       // 如: "/bin/kill -HUP $MAINPID"
+	ExecStop         string            `json:"exec_stop,omitempty"`         // 未设置时直接发送 KillSignal
+	Type             string            `json:"type,omitempty"`              // simple, exec, forking, oneshot, notify, idle，默认 simple
+	PIDFile          string            `json:"pid_file,omitempty"`          // forking 服务的 PID 文件
+	RemainAfterExit  bool              `json:"remain_after_exit,omitempty"` // 进程退出后仍视为活动，常用于 oneshot
 	User             string            `json:"user,omitempty"`
 	Group            string            `json:"group,omitempty"`
+	UMask            string            `json:"umask,omitempty"` // 如: "0027"
+	Nice             *int              `json:"nice,omitempty"`  // -20 到 19
 	Environment      map[string]string `json:"environment,omitempty"`
+	EnvironmentFile  []string          `json:"environment_file,omitempty"` // 如: "/etc/default/app"，"-" 前缀表示文件可以不存在
 
 	// 重启策略
-	RestartPolicy   string        `json:"restart_policy"` // no, always, on-failure
-	RestartDelaySec time.Duration `json:"restart_delay_sec"`
-	StartLimitBurst int           `json:"start_limit_burst"`
+	RestartPolicy         string        `json:"restart_policy"` // no, always, on-success, on-failure, on-abnormal, on-abort, on-watchdog
+	RestartDelaySec       time.Duration `json:"restart_delay_sec"`
+	StartLimitBurst       int           `json:"start_limit_burst"`
+	StartLimitIntervalSec string        `json:"start_limit_interval_sec,omitempty"` // systemd 时间格式，如 "10s"，"0" 表示不限制
+
+	// 启停与超时，时间使用 systemd 时间格式（如 "90s"、"5min"、"infinity"）
+	TimeoutStartSec   string   `json:"timeout_start_sec,omitempty"`
+	TimeoutStopSec    string   `json:"timeout_stop_sec,omitempty"`
+	KillMode          string   `json:"kill_mode,omitempty"`           // control-group, mixed, process, none
+	KillSignal        string   `json:"kill_signal,omitempty"`         // 如: "SIGINT"
+	SuccessExitStatus []string `json:"success_exit_status,omitempty"` // 视为正常退出的状态码或信号，如: "143", "SIGTERM"
+
+	// 标准输出，如: journal, null, file:/var/log/app.log, append:/var/log/app.log
+	StandardOutput string `json:"standard_output,omitempty"`
+	StandardError  string `json:"standard_error,omitempty"`
 
 	// 资源限制
 	MemoryLimit string `json:"memory_limit,omitempty"` // 如: "1G"
 	CPUQuota    string `json:"cpu_quota,omitempty"`    // 如: "50%"
 	TasksMax    int    `json:"tasks_max,omitempty"`
+	LimitNOFILE string `json:"limit_nofile,omitempty"` // 如: "65536"、"1024:65536"、"infinity"
+
+	// 沙箱加固
+	Hardening *HardeningConfig `json:"hardening,omitempty"`
 
 	// 依赖关系
 	After    []string `json:"after,omitempty"`
@@ -94,6 +120,9 @@ type ServiceConfig struct {
 	Requires []string `json:"requires,omitempty"`
 	Wants    []string `json:"wants,omitempty"`
 
+	// 安装目标，默认为 multi-user.target，用户管理器下为 default.target
+	InstallTarget string `json:"install_target,omitempty"`
+
 	// 生命周期钩子
 	Hooks []Hook `json:"hooks"`
 
@@ -102,6 +131,60 @@ type ServiceConfig struct {
 
 	// 健康检查
 	HealthCheck *HealthCheckConfig `json:"health_check,omitempty"`
+
+	// 套接字激活，设置时同时部署配对的 .socket 单元
+	Socket *SocketConfig `json:"socket,omitempty"`
+
+	// 未建模的配置项，按原顺序写入单元文件对应的小节
+	ExtraOptions []UnitOption `json:"extra_options,omitempty"`
+}
+
+// 加固预设
+const (
+	HardeningNone     = "none"     // 不加固
+	HardeningStandard = "standard" // 只读系统目录、私有 /tmp 和设备，兼容大多数服务
+	HardeningStrict   = "strict"   // 整个文件系统只读，清空能力集并限制系统调用
+)
+
+// HardeningConfig 沙箱加固配置，Profile 展开为一组选项，设置的字段覆盖预设值
+type HardeningConfig struct {
+	Profile                 string   `json:"profile,omitempty"`        // none, standard, strict，默认 none
+	ProtectSystem           string   `json:"protect_system,omitempty"` // no, yes, full, strict
+	ProtectHome             string   `json:"protect_home,omitempty"`   // no, yes, read-only, tmpfs
+	PrivateTmp              *bool    `json:"private_tmp,omitempty"`
+	NoNewPrivileges         *bool    `json:"no_new_privileges,omitempty"`
+	PrivateDevices          *bool    `json:"private_devices,omitempty"`
+	DynamicUser             *bool    `json:"dynamic_user,omitempty"`              // 以临时分配的用户运行，不受预设影响
+	RestrictAddressFamilies []string `json:"restrict_address_families,omitempty"` // 如: "AF_UNIX", "AF_INET"
+	CapabilityBoundingSet   []string `json:"capability_bounding_set"`             // 空列表表示清空能力集，null 表示不限制
+	SystemCallFilter        []string `json:"system_call_filter,omitempty"`        // 如: "@system-service", "~@privileged"
+	ReadWritePaths          []string `json:"read_write_paths,omitempty"`          // 追加在服务目录和日志目录之后
+}
+
+// UnitOption 单元文件中的一条配置项
+type UnitOption struct {
+	Section string `json:"section"` // 如: Unit, Service, Install
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+}
+
+// SocketConfig 套接字激活配置，监听地址如 "8080"、"127.0.0.1:8080"、"[::]:53" 或 "/run/app.sock"
+type SocketConfig struct {
+	ListenStream       []string `json:"listen_stream,omitempty"`        // TCP 或 Unix 流式套接字
+	ListenDatagram     []string `json:"listen_datagram,omitempty"`      // UDP 或 Unix 数据报套接字
+	Accept             bool     `json:"accept"`                         // 每个连接启动一个实例，服务需以模板单元部署
+	SocketUser         string   `json:"socket_user,omitempty"`          // Unix 套接字文件的属主
+	SocketMode         string   `json:"socket_mode,omitempty"`          // Unix 套接字文件的权限，如: "0660"
+	FileDescriptorName string   `json:"file_descriptor_name,omitempty"` // 传给服务的文件描述符名（$LISTEN_FDNAMES）
+}
+
+// TimerConfig 定时器配置，时间间隔使用 systemd 时间格式（如 "5min"、"1h 30min"）
+type TimerConfig struct {
+	OnCalendar         []string `json:"on_calendar,omitempty"`          // 如: "*-*-* 02:00:00"
+	OnBootSec          string   `json:"on_boot_sec,omitempty"`          // 开机后延迟触发
+	OnUnitActiveSec    string   `json:"on_unit_active_sec,omitempty"`   // 上次触发后间隔触发
+	Persistent         bool     `json:"persistent"`                     // 补执行关机期间错过的任务
+	RandomizedDelaySec string   `json:"randomized_delay_sec,omitempty"` // 随机延迟
 }
 
 // HealthCheckConfig 健康检查配置
@@ -1,11 +1,14 @@
 package logs
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // LogEntry 日志条目
@@ -13,12 +16,20 @@ type LogEntry struct {
 	Timestamp string `json:"timestamp"`
 	Message   string `json:"message"`
 	Level     string `json:"level"`
+	Unit      string `json:"unit,omitempty"` // 产生日志的单元，按模板查询多个实例时用于区分
+}
+
+// unitArgs 返回按单元过滤日志的参数，user 为 true 时查询 systemd --user 管理的单元
+func unitArgs(serviceName string, user bool) []string {
+	if user {
+		return []string{"--user-unit", serviceName}
+	}
+	return []string{"-u", serviceName}
 }
 
 // GetServiceLogs 获取服务日志
-func GetServiceLogs(ctx context.Context, serviceName string, lines int) ([]LogEntry, error) {
-	var args []string
-	args = append(args, "-u", serviceName)
+func GetServiceLogs(ctx context.Context, serviceName string, lines int, user bool) ([]LogEntry, error) {
+	args := unitArgs(serviceName, user)
 
 	if lines > 0 {
 		args = append(args, "-n", strconv.Itoa(lines))
@@ -36,7 +47,8 @@ func GetServiceLogs(ctx context.Context, serviceName string, lines int) ([]LogEn
 }
 
 // GetServiceLogsFollow 实时跟踪服务日志
-func GetServiceLogsFollow(ctx context.Context, serviceName string) (<-chan LogEntry, <-chan error) {
+// 先输出最近 lines 行（小于0时输出全部历史），ctx 取消时停止跟踪并关闭通道
+func GetServiceLogsFollow(ctx context.Context, serviceName string, lines int, user bool) (<-chan LogEntry, <-chan error) {
 	logChan := make(chan LogEntry, 100)
 	errChan := make(chan error, 1)
 
@@ -44,17 +56,39 @@ func GetServiceLogsFollow(ctx context.Context, serviceName string) (<-chan LogEn
 		defer close(logChan)
 		defer close(errChan)
 
-		cmd := exec.CommandContext(ctx, "journalctl", "-u", serviceName, "-f", "--no-pager", "--output=json")
+		n := "all"
+		if lines >= 0 {
+			n = strconv.Itoa(lines)
+		}
+		args := append(unitArgs(serviceName, user), "-f", "-n", n, "--no-pager", "--output=json")
+		cmd := exec.CommandContext(ctx, "journalctl", args...)
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			errChan <- err
+			return
+		}
 
 		if err := cmd.Start(); err != nil {
 			errChan <- err
 			return
 		}
 
-		// 这里可以添加实时日志解析逻辑
-		// 简化版本，实际使用时需要更复杂的解析
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			entry, ok := parseJournalLine(scanner.Text())
+			if !ok {
+				continue
+			}
+			select {
+			case logChan <- entry:
+			case <-ctx.Done():
+			}
+		}
 
-		if err := cmd.Wait(); err != nil {
+		// ctx 取消导致的退出属于正常结束
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
 			errChan <- err
 		}
 	}()
@@ -68,19 +102,81 @@ func parseJournalOutput(output string) ([]LogEntry, error) {
 
 	lines := strings.Split(output, "\n")
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
+		entry, ok := parseJournalLine(line)
+		if !ok {
 			continue
 		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// journalPriorities syslog 优先级到日志级别的映射
+var journalPriorities = map[string]string{
+	"0": "emerg",
+	"1": "alert",
+	"2": "crit",
+	"3": "error",
+	"4": "warning",
+	"5": "notice",
+	"6": "info",
+	"7": "debug",
+}
 
-		// 简化的解析，实际应该使用JSON解析
-		entry := LogEntry{
+// parseJournalLine 解析一行 journalctl JSON 输出，无法解析时按原文返回
+func parseJournalLine(line string) (LogEntry, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return LogEntry{}, false
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return LogEntry{
 			Timestamp: "unknown",
 			Message:   line,
 			Level:     "info",
+		}, true
+	}
+
+	entry := LogEntry{
+		Timestamp: "unknown",
+		Level:     "info",
+	}
+
+	// MESSAGE 为二进制内容时 journalctl 输出字节数组
+	switch msg := record["MESSAGE"].(type) {
+	case string:
+		entry.Message = msg
+	case []interface{}:
+		b := make([]byte, 0, len(msg))
+		for _, v := range msg {
+			if f, ok := v.(float64); ok {
+				b = append(b, byte(f))
+			}
 		}
-		entries = append(entries, entry)
+		entry.Message = string(b)
 	}
 
-	return entries, nil
+	if ts, ok := record["__REALTIME_TIMESTAMP"].(string); ok {
+		if usec, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			entry.Timestamp = time.UnixMicro(usec).UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	if unit, ok := record["_SYSTEMD_UNIT"].(string); ok {
+		entry.Unit = unit
+	}
+	if unit, ok := record["_SYSTEMD_USER_UNIT"].(string); ok {
+		entry.Unit = unit
+	}
+
+	if priority, ok := record["PRIORITY"].(string); ok {
+		if level, ok := journalPriorities[priority]; ok {
+			entry.Level = level
+		}
+	}
+
+	return entry, true
 }
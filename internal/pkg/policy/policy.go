@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Operation 受策略控制的变更操作
+type Operation string
+
+const (
+	OpDeploy      Operation = "deploy"
+	OpAdopt       Operation = "adopt"
+	OpStart       Operation = "start"
+	OpStop        Operation = "stop"
+	OpRestart     Operation = "restart"
+	OpReload      Operation = "reload"
+	OpKill        Operation = "kill"
+	OpResetFailed Operation = "reset-failed"
+	OpMask        Operation = "mask"
+	OpUnmask      Operation = "unmask"
+	OpResources   Operation = "resources"
+	OpDropIn      Operation = "dropin"
+	OpRun         Operation = "run"
+	OpTrigger     Operation = "trigger"
+	OpRemove      Operation = "remove"
+	OpRestore     Operation = "restore"
+	OpScale       Operation = "scale"
+	OpRollback    Operation = "rollback"
+
+	// OpAll 匹配所有操作
+	OpAll Operation = "*"
+)
+
+// operations 可在规则中使用的操作
+var operations = []Operation{
+	OpDeploy, OpAdopt, OpStart, OpStop, OpRestart, OpReload, OpKill, OpResetFailed,
+	OpMask, OpUnmask, OpResources, OpDropIn, OpRun, OpTrigger, OpRemove, OpRestore, OpScale, OpRollback, OpAll,
+}
+
+// DefaultProtected 未配置 protected 时默认保护的关键单元
+var DefaultProtected = []string{
+	"sshd", "ssh", "dbus", "dbus-broker", "systemd-*", "getty@*", "serial-getty@*", "user@*",
+	"NetworkManager", "NetworkManager-*", "networking", "network", "wpa_supplicant",
+	"firewalld", "iptables", "nftables", "auditd", "polkit", "chronyd", "rsyslog", "crond", "cron",
+	"api-systemd",
+	"*.target", "*.slice", "*.scope", "*.mount", "*.swap", "*.device",
+}
+
+// ErrDenied 操作被策略拒绝
+var ErrDenied = errors.New("operation denied by policy")
+
+// Rule 针对部分单元的操作规则，deny 优先于 allow
+type Rule struct {
+	Units []string    `json:"units"`           // 单元名 glob，如 "nginx"、"worker-*"
+	Allow []Operation `json:"allow,omitempty"` // 允许的操作，可放行受保护单元
+	Deny  []Operation `json:"deny,omitempty"`  // 禁止的操作
+}
+
+// Config 策略配置
+// 单元名 glob 使用 path.Match 语法，不带类型后缀的模式同时匹配去掉 .service 后缀的名称
+type Config struct {
+	Allow     []string `json:"allow,omitempty"`     // 非空时只允许匹配的单元
+	Deny      []string `json:"deny,omitempty"`      // 禁止所有操作的单元
+	Protected []string `json:"protected,omitempty"` // 只能执行规则明确允许的操作，为 null 时使用 DefaultProtected
+	Rules     []Rule   `json:"rules,omitempty"`
+}
+
+// Policy 单元操作策略
+type Policy struct {
+	config Config
+}
+
+// New 创建策略，校验 glob 和操作名
+func New(config Config) (*Policy, error) {
+	if config.Protected == nil {
+		config.Protected = DefaultProtected
+	}
+
+	patterns := append(append(append([]string{}, config.Allow...), config.Deny...), config.Protected...)
+	for i, rule := range config.Rules {
+		if len(rule.Units) == 0 {
+			return nil, fmt.Errorf("rule %d: units cannot be empty", i)
+		}
+		patterns = append(patterns, rule.Units...)
+		for _, op := range append(append([]Operation{}, rule.Allow...), rule.Deny...) {
+			if !validOperation(op) {
+				return nil, fmt.Errorf("rule %d: unknown operation %q", i, op)
+			}
+		}
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid unit pattern %q: %w", pattern, err)
+		}
+	}
+
+	return &Policy{config: config}, nil
+}
+
+// Load 从 JSON 文件加载策略，file 为空时使用默认策略
+func Load(file string) (*Policy, error) {
+	if file == "" {
+		return New(Config{})
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", file, err)
+	}
+
+	policy, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", file, err)
+	}
+	return policy, nil
+}
+
+// Check 检查是否允许对单元执行操作，拒绝时返回包装 ErrDenied 的错误
+// 依次检查：规则 deny、全局 deny、规则 allow、受保护单元、全局 allow
+func (p *Policy) Check(unit string, op Operation) error {
+	for _, rule := range p.config.Rules {
+		if matchAny(rule.Units, unit) && hasOperation(rule.Deny, op) {
+			return denied(unit, op, "denied by rule")
+		}
+	}
+	if matchAny(p.config.Deny, unit) {
+		return denied(unit, op, "unit is in deny list")
+	}
+	for _, rule := range p.config.Rules {
+		if matchAny(rule.Units, unit) && hasOperation(rule.Allow, op) {
+			return nil
+		}
+	}
+	if matchAny(p.config.Protected, unit) {
+		return denied(unit, op, "unit is protected")
+	}
+	if len(p.config.Allow) > 0 && !matchAny(p.config.Allow, unit) {
+		return denied(unit, op, "unit is not in allow list")
+	}
+	return nil
+}
+
+// denied 构造拒绝错误
+func denied(unit string, op Operation, reason string) error {
+	return fmt.Errorf("%w: %s on %s: %s", ErrDenied, op, unit, reason)
+}
+
+// matchAny 判断单元名是否匹配任一 glob
+func matchAny(patterns []string, unit string) bool {
+	name := strings.TrimSuffix(unit, ".service")
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, unit); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOperation 判断操作列表是否包含 op
+func hasOperation(list []Operation, op Operation) bool {
+	for _, o := range list {
+		if o == op || o == OpAll {
+			return true
+		}
+	}
+	return false
+}
+
+// validOperation 判断操作名是否有效
+func validOperation(op Operation) bool {
+	for _, o := range operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchAny(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		unit     string
+		want     bool
+	}{
+		{[]string{"nginx"}, "nginx", true},
+		{[]string{"nginx"}, "nginx.service", true},
+		{[]string{"nginx.service"}, "nginx.service", true},
+		{[]string{"nginx"}, "nginx.socket", false},
+		{[]string{"worker-*"}, "worker-1.service", true},
+		{[]string{"getty@*"}, "getty@tty1.service", true},
+		{[]string{"*.target"}, "multi-user.target", true},
+		{[]string{"*.target"}, "app.service", false},
+		{[]string{"a", "b"}, "b.service", true},
+		{nil, "app.service", false},
+	}
+	for _, tt := range tests {
+		if got := matchAny(tt.patterns, tt.unit); got != tt.want {
+			t.Errorf("matchAny(%v, %q) = %v, want %v", tt.patterns, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p, err := New(Config{
+		Allow:     []string{"app-*", "nginx", "sshd"},
+		Deny:      []string{"app-legacy"},
+		Protected: []string{"sshd", "nginx"},
+		Rules: []Rule{
+			{Units: []string{"nginx"}, Allow: []Operation{OpReload, OpRestart}},
+			{Units: []string{"app-*"}, Deny: []Operation{OpKill}},
+			{Units: []string{"app-legacy"}, Allow: []Operation{OpAll}},
+			{Units: []string{"sshd"}, Allow: []Operation{OpAll}, Deny: []Operation{OpStop}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		unit    string
+		op      Operation
+		allowed bool
+	}{
+		{"app-web.service", OpDeploy, true},
+		{"app-web.service", OpKill, false},     // 规则 deny
+		{"app-legacy.service", OpStart, false}, // 全局 deny 优先于规则 allow
+		{"nginx.service", OpReload, true},      // 规则 allow 放行受保护单元
+		{"nginx.service", OpStop, false},       // 受保护
+		{"sshd.service", OpRestart, true},      // 规则 allow *
+		{"sshd.service", OpStop, false},        // 同一规则中 deny 优先
+		{"other.service", OpStart, false},      // 不在 allow 列表
+		{"nginx.socket", OpStart, false},       // 不带后缀的模式只匹配 .service
+		{"app-web.service", OpRollback, true},
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.unit, tt.op)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("Check(%q, %s) = %v, want allowed %v", tt.unit, tt.op, err, tt.allowed)
+		}
+		if err != nil && !errors.Is(err, ErrDenied) {
+			t.Errorf("Check(%q, %s) = %v, want ErrDenied", tt.unit, tt.op, err)
+		}
+	}
+}
+
+func TestDefaultProtected(t *testing.T) {
+	p, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		unit    string
+		allowed bool
+	}{
+		{"sshd.service", false},
+		{"systemd-journald.service", false},
+		{"getty@tty1.service", false},
+		{"multi-user.target", false},
+		{"api-systemd.service", false},
+		{"myapp.service", true},
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.unit, OpStop)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("Check(%q) = %v, want allowed %v", tt.unit, err, tt.allowed)
+		}
+	}
+
+	// 显式配置为空列表时不保护任何单元
+	p, err = New(Config{Protected: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Check("sshd.service", OpStop); err != nil {
+		t.Errorf("Check with empty protected list = %v, want nil", err)
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"invalid allow pattern", Config{Allow: []string{"app-["}}},
+		{"invalid rule pattern", Config{Rules: []Rule{{Units: []string{"["}}}}},
+		{"empty rule units", Config{Rules: []Rule{{Allow: []Operation{OpStart}}}}},
+		{"unknown operation", Config{Rules: []Rule{{Units: []string{"app"}, Deny: []Operation{"explode"}}}}},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.config); err == nil {
+			t.Errorf("%s: New succeeded, want error", tt.name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "policy.json")
+	if err := os.WriteFile(valid, []byte(`{"deny":["db"],"rules":[{"units":["web"],"deny":["stop"]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"deny":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Check("db.service", OpStart); !errors.Is(err, ErrDenied) {
+		t.Errorf("Check(db) = %v, want ErrDenied", err)
+	}
+	if err := p.Check("web.service", OpStop); !errors.Is(err, ErrDenied) {
+		t.Errorf("Check(web, stop) = %v, want ErrDenied", err)
+	}
+
+	if _, err := Load(invalid); err == nil {
+		t.Error("Load(invalid) succeeded, want error")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Load(missing) succeeded, want error")
+	}
+	if _, err := Load(""); err != nil {
+		t.Errorf("Load(\"\") = %v, want default policy", err)
+	}
+}
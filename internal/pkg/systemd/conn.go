@@ -0,0 +1,380 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"api-systemd/internal/pkg/logger"
+
+	"github.com/godbus/dbus"
+)
+
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// Health D-Bus 连接健康状态
+type Health struct {
+	Bus         string    `json:"bus"` // system 或 session
+	Connected   bool      `json:"connected"`
+	ConnectedAt time.Time `json:"connected_at,omitempty"`
+	Reconnects  int       `json:"reconnects"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+}
+
+// Manager 长连接的 systemd D-Bus 管理器
+// 持有一个私有的系统总线（用户模式下为会话总线）连接，连接断开后在后台自动重连
+type Manager struct {
+	userMode bool // 是否连接 systemd --user 用户管理器
+
+	mu          sync.Mutex
+	conn        *dbus.Conn
+	connectedAt time.Time
+	reconnects  int
+	lastErr     error
+	lastErrAt   time.Time
+	dialing     chan struct{} // 正在建立连接时非空，连接建立完成或失败后关闭
+	reconnectCh chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+
+	// 信号订阅，每次建立连接后重新注册
+	matches  []string
+	handlers []func(*dbus.Signal)
+
+	jobs   *jobTracker
+	states *stateWatcher
+}
+
+// NewManager 创建 systemd 管理器并尝试建立初始连接
+// userMode 为 true 时通过会话总线管理当前用户的 systemd --user 实例
+// 初始连接失败不会返回错误，管理器会在后台持续重连
+func NewManager(userMode bool) *Manager {
+	m := &Manager{
+		userMode:    userMode,
+		reconnectCh: make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		jobs:        newJobTracker(),
+		states:      newStateWatcher(),
+	}
+
+	m.onSignal(jobRemovedMatch, m.jobs.handleSignal)
+	m.onSignal(propertiesChangedMatch, m.states.handleSignal)
+	m.onSignal(unitRemovedMatch, m.states.handleUnitRemoved)
+
+	if _, err := m.getConn(context.Background()); err != nil {
+		logger.Warn(context.Background(), "Initial D-Bus connection failed, will retry in background", "error", err)
+		m.scheduleReconnect()
+	}
+
+	go m.reconnectLoop()
+	return m
+}
+
+// UserMode 是否为 systemd --user 用户管理器模式
+func (m *Manager) UserMode() bool {
+	return m.userMode
+}
+
+// busName 返回连接的总线名称
+func (m *Manager) busName() string {
+	if m.userMode {
+		return "session"
+	}
+	return "system"
+}
+
+// Close 关闭管理器及其持有的连接
+func (m *Manager) Close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		close(m.done)
+
+		m.mu.Lock()
+		conn := m.conn
+		m.conn = nil
+		m.mu.Unlock()
+
+		if conn != nil {
+			err = conn.Close()
+		}
+	})
+	return err
+}
+
+// Health 返回当前连接的健康状态
+func (m *Manager) Health() Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := Health{
+		Bus:         m.busName(),
+		Connected:   m.conn != nil,
+		ConnectedAt: m.connectedAt,
+		Reconnects:  m.reconnects,
+		LastErrorAt: m.lastErrAt,
+	}
+	if m.lastErr != nil {
+		h.LastError = m.lastErr.Error()
+	}
+	return h
+}
+
+// getConn 获取当前连接，未连接时在后台建立新连接并等待其完成
+// 拨号和订阅不持有 m.mu，总线阻塞时 Health 不受影响，调用方可以通过 ctx 提前返回
+func (m *Manager) getConn(ctx context.Context) (*dbus.Conn, error) {
+	m.mu.Lock()
+	select {
+	case <-m.done:
+		m.mu.Unlock()
+		return nil, errors.New("systemd manager is closed")
+	default:
+	}
+
+	// 连接已建立且完成订阅
+	if m.conn != nil && m.dialing == nil {
+		conn := m.conn
+		m.mu.Unlock()
+		return conn, nil
+	}
+
+	// 同一时间只有一个拨号协程，其余调用方等待其结果
+	wait := m.dialing
+	if wait == nil {
+		wait = make(chan struct{})
+		m.dialing = wait
+		go m.dial(wait)
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-wait:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		return nil, fmt.Errorf("failed to connect to %s bus: %w", m.busName(), m.lastErr)
+	}
+	return m.conn, nil
+}
+
+// dial 建立新连接并重新订阅信号，完成后关闭 done
+// 订阅完成前其他调用方仍在等待 done，避免在订阅前发起的任务丢失 JobRemoved 信号
+func (m *Manager) dial(done chan struct{}) {
+	defer close(done)
+
+	conn, err := dialBus(m.userMode)
+
+	m.mu.Lock()
+	select {
+	case <-m.done:
+		if err == nil {
+			conn.Close()
+		}
+		err = errors.New("systemd manager is closed")
+	default:
+	}
+	if err != nil {
+		m.dialing = nil
+		m.recordErrorLocked(err)
+		m.mu.Unlock()
+		return
+	}
+
+	if !m.connectedAt.IsZero() {
+		m.reconnects++
+	}
+	m.conn = conn
+	m.connectedAt = time.Now()
+	m.mu.Unlock()
+
+	// 连接关闭时信号通道会被关闭，以此感知连接断开
+	signals := make(chan *dbus.Signal, 64)
+	conn.Signal(signals)
+	go m.watch(conn, signals, m.handlers)
+
+	m.subscribe(conn)
+	m.reconcileJobs(conn)
+
+	m.mu.Lock()
+	m.dialing = nil
+	m.mu.Unlock()
+}
+
+// reconcileJobs 订阅后查询仍在执行的任务，结束断线期间丢失 JobRemoved 信号的任务
+// 查询失败时无法确认任何任务仍在执行，全部以 unknown 结果结束，避免等待方永久阻塞
+func (m *Manager) reconcileJobs(conn *dbus.Conn) {
+	active := map[dbus.ObjectPath]bool{}
+
+	var jobs [][]interface{}
+	err := conn.Object(destBus, objectPath).Call(mngerMethod+".ListJobs", 0).Store(&jobs)
+	if err != nil {
+		logger.Warn(context.Background(), "Failed to list running jobs after connecting", "error", err)
+	}
+	for _, job := range jobs {
+		if len(job) < 5 {
+			continue
+		}
+		if path, ok := job[4].(dbus.ObjectPath); ok {
+			active[path] = true
+		}
+	}
+
+	if n := m.jobs.reconcile(active); n > 0 {
+		logger.Warn(context.Background(), "Jobs finished while D-Bus was disconnected, result unknown", "jobs", n)
+	}
+}
+
+// onSignal 注册信号匹配规则和处理函数，需在建立连接前调用
+func (m *Manager) onSignal(match string, handler func(*dbus.Signal)) {
+	m.matches = append(m.matches, match)
+	m.handlers = append(m.handlers, handler)
+}
+
+// subscribe 在新连接上注册信号匹配并订阅 systemd 事件
+func (m *Manager) subscribe(conn *dbus.Conn) {
+	ctx := context.Background()
+
+	for _, match := range m.matches {
+		if err := conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, match).Err; err != nil {
+			logger.Warn(ctx, "Failed to add D-Bus signal match", "match", match, "error", err)
+		}
+	}
+
+	// systemd 只会向订阅过的客户端发送 JobRemoved、PropertiesChanged 等信号
+	if err := conn.Object(destBus, objectPath).Call(mngerMethod+".Subscribe", 0).Err; err != nil {
+		logger.Warn(ctx, "Failed to subscribe to systemd signals", "error", err)
+	}
+}
+
+// dialBus 建立私有的系统总线连接，用户模式下连接会话总线
+func dialBus(userMode bool) (*dbus.Conn, error) {
+	dial := dbus.SystemBusPrivate
+	if userMode {
+		dial = dbus.SessionBusPrivate
+	}
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// watch 分发连接上的信号，通道关闭即表示连接已断开
+func (m *Manager) watch(conn *dbus.Conn, signals chan *dbus.Signal, handlers []func(*dbus.Signal)) {
+	for sig := range signals {
+		for _, handler := range handlers {
+			handler(sig)
+		}
+	}
+
+	m.mu.Lock()
+	if m.conn != conn {
+		m.mu.Unlock()
+		return
+	}
+	m.conn = nil
+	m.recordErrorLocked(errors.New("D-Bus connection lost"))
+	m.mu.Unlock()
+
+	logger.Warn(context.Background(), "D-Bus connection lost, reconnecting")
+	m.scheduleReconnect()
+}
+
+// scheduleReconnect 通知后台协程进行重连
+func (m *Manager) scheduleReconnect() {
+	select {
+	case m.reconnectCh <- struct{}{}:
+	default:
+	}
+}
+
+// reconnectLoop 后台重连协程，使用指数退避
+func (m *Manager) reconnectLoop() {
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.reconnectCh:
+		}
+
+		delay := reconnectMinDelay
+		for {
+			if _, err := m.getConn(context.Background()); err == nil {
+				logger.Info(context.Background(), "D-Bus connection re-established")
+				break
+			}
+
+			select {
+			case <-m.done:
+				return
+			case <-time.After(delay):
+			}
+
+			delay *= 2
+			if delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+		}
+	}
+}
+
+// recordErrorLocked 记录最近一次连接错误，调用方需持有锁
+func (m *Manager) recordErrorLocked(err error) {
+	m.lastErr = err
+	m.lastErrAt = time.Now()
+}
+
+// call 在 systemd 对象上调用方法，遵循请求上下文的取消和超时
+func (m *Manager) call(ctx context.Context, path dbus.ObjectPath, method string, args ...interface{}) (*dbus.Call, error) {
+	conn, err := m.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := conn.Object(destBus, path).Go(method, 0, make(chan *dbus.Call, 1), args...)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case call := <-pending.Done:
+		if call.Err != nil {
+			if isConnError(call.Err) {
+				m.mu.Lock()
+				m.recordErrorLocked(call.Err)
+				m.mu.Unlock()
+				// 关闭连接会触发 watch 完成重连
+				conn.Close()
+			}
+			return call, call.Err
+		}
+		return call, nil
+	}
+}
+
+// isConnError 判断是否为连接层面的错误（而非 systemd 返回的业务错误）
+func isConnError(err error) bool {
+	if errors.Is(err, dbus.ErrClosed) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,211 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus"
+)
+
+const (
+	// DefaultDependencyDepth 默认的依赖遍历深度
+	DefaultDependencyDepth = 2
+	// MaxDependencyDepth 允许的最大依赖遍历深度
+	MaxDependencyDepth = 10
+	// maxDependencyNodes 单次遍历最多加载的单元数，避免遍历整个单元树
+	maxDependencyNodes = 256
+)
+
+// 依赖边类型
+const (
+	EdgeRequires = "requires" // From 强依赖 To
+	EdgeWants    = "wants"    // From 弱依赖 To
+	EdgeAfter    = "after"    // From 在 To 之后启动
+)
+
+// dependencyProperties 读取的 Unit 依赖属性，反向属性会转换为正向边
+var dependencyProperties = []struct {
+	property string
+	edge     string
+	reverse  bool
+}{
+	{"Requires", EdgeRequires, false},
+	{"Wants", EdgeWants, false},
+	{"After", EdgeAfter, false},
+	{"Before", EdgeAfter, true},
+	{"RequiredBy", EdgeRequires, true},
+	{"WantedBy", EdgeWants, true},
+}
+
+// DependencyNode 依赖图中的单元
+type DependencyNode struct {
+	Name        string `json:"name"`
+	Depth       int    `json:"depth"` // 与根单元的距离
+	LoadState   string `json:"load_state"`
+	ActiveState string `json:"active_state"`
+	SubState    string `json:"sub_state"`
+	Error       string `json:"error,omitempty"`
+}
+
+// DependencyEdge 依赖图中的边
+type DependencyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"` // requires, wants, after
+}
+
+// DependencyGraph 以根单元为起点的依赖图
+type DependencyGraph struct {
+	Root      string           `json:"root"`
+	Depth     int              `json:"depth"`
+	Truncated bool             `json:"truncated"` // 是否因深度或数量限制未完全展开
+	Nodes     []DependencyNode `json:"nodes"`
+	Edges     []DependencyEdge `json:"edges"`
+}
+
+// Dependencies 从 Unit 的 Requires/Wants/After/Before/RequiredBy/WantedBy 属性构建依赖图
+// 按广度优先遍历至 maxDepth 层，Before、RequiredBy、WantedBy 转换为反向的 after、requires、wants 边
+func (m *Manager) Dependencies(ctx context.Context, serviceName string, maxDepth int) (*DependencyGraph, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultDependencyDepth
+	}
+	if maxDepth > MaxDependencyDepth {
+		maxDepth = MaxDependencyDepth
+	}
+
+	root := UnitName(serviceName)
+	graph := &DependencyGraph{
+		Root:  root,
+		Depth: maxDepth,
+		Nodes: []DependencyNode{},
+		Edges: []DependencyEdge{},
+	}
+
+	depths := map[string]int{root: 0}
+	edges := map[DependencyEdge]struct{}{}
+	queue := []string{root}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		depth := depths[name]
+		node := DependencyNode{Name: name, Depth: depth}
+
+		props, err := m.unitProperties(ctx, name)
+		if err == nil && name == root &&
+			propString(props, "LoadState") == "not-found" && propString(props, "ActiveState") == "inactive" {
+			err = &UnitNotFoundError{Unit: root}
+		}
+		if err != nil {
+			if name == root {
+				return nil, err
+			}
+			// 依赖单元无法读取时保留节点，不影响整体结果
+			node.Error = err.Error()
+			graph.Nodes = append(graph.Nodes, node)
+			continue
+		}
+
+		node.LoadState = propString(props, "LoadState")
+		node.ActiveState = propString(props, "ActiveState")
+		node.SubState = propString(props, "SubState")
+		graph.Nodes = append(graph.Nodes, node)
+
+		for _, dep := range dependencyProperties {
+			for _, other := range propStrings(props, dep.property) {
+				if _, seen := depths[other]; !seen {
+					if depth >= maxDepth || len(depths) >= maxDependencyNodes {
+						graph.Truncated = true
+						continue
+					}
+					depths[other] = depth + 1
+					queue = append(queue, other)
+				}
+
+				edge := DependencyEdge{From: name, To: other, Type: dep.edge}
+				if dep.reverse {
+					edge.From, edge.To = other, name
+				}
+				edges[edge] = struct{}{}
+			}
+		}
+	}
+
+	for edge := range edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Type < b.Type
+	})
+
+	return graph, nil
+}
+
+// unitProperties 读取单元的 Unit 接口属性
+func (m *Manager) unitProperties(ctx context.Context, name string) (map[string]dbus.Variant, error) {
+	path, err := m.unitPath(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	props, err := m.getAllProperties(ctx, path, destUnit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit properties: %w", err)
+	}
+	return props, nil
+}
+
+// DOT 将依赖图渲染为 Graphviz DOT 格式
+func (g *DependencyGraph) DOT() string {
+	var b strings.Builder
+
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+
+	for _, node := range g.Nodes {
+		label := node.Name
+		if node.ActiveState != "" {
+			label += "\\n" + node.ActiveState + " (" + node.SubState + ")"
+		}
+		attrs := []string{"label=" + dotQuote(label)}
+		if node.Name == g.Root {
+			attrs = append(attrs, "style=bold")
+		}
+		switch {
+		case node.Error != "" || node.ActiveState == "failed":
+			attrs = append(attrs, "color=red")
+		case node.ActiveState == "active":
+			attrs = append(attrs, "color=darkgreen")
+		}
+		fmt.Fprintf(&b, "\t%s [%s];\n", dotQuote(node.Name), strings.Join(attrs, ", "))
+	}
+
+	for _, edge := range g.Edges {
+		style := "solid"
+		switch edge.Type {
+		case EdgeWants:
+			style = "dashed"
+		case EdgeAfter:
+			style = "dotted"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s, style=%s];\n",
+			dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Type), style)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote 转义为 DOT 双引号字符串
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -1,5 +1,7 @@
 package systemd
 
+import "time"
+
 type Unit struct {
 	Name          string `json:"name"`
 	Service       string `json:"service"`
@@ -9,4 +11,17 @@ type Unit struct {
 	SubState      string `json:"sub_state"`
 	UnitFileState string `json:"unit_file_state"`
 	PID           int    `json:"pid"`
+
+	// 运行时信息，仅在加载单个单元时填充
+	Result                string     `json:"result,omitempty"`
+	MemoryCurrent         *uint64    `json:"memory_current,omitempty"` // 字节
+	CPUUsageNSec          *uint64    `json:"cpu_usage_nsec,omitempty"`
+	TasksCurrent          *uint64    `json:"tasks_current,omitempty"`
+	NRestarts             uint32     `json:"n_restarts"`
+	ExecMainStatus        int32      `json:"exec_main_status"`
+	ExecMainCode          int32      `json:"exec_main_code"` // 1=exited, 2=killed, 3=dumped
+	ActiveEnterTimestamp  *time.Time `json:"active_enter_timestamp,omitempty"`
+	InactiveExitTimestamp *time.Time `json:"inactive_exit_timestamp,omitempty"`
+	FragmentPath          string     `json:"fragment_path,omitempty"`
+	DropInPaths           []string   `json:"drop_in_paths,omitempty"`
 }
@@ -0,0 +1,72 @@
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/godbus/dbus"
+)
+
+// systemd D-Bus 错误名
+const (
+	errNoSuchUnit           = "org.freedesktop.systemd1.NoSuchUnit"
+	errJobTypeNotApplicable = "org.freedesktop.systemd1.JobTypeNotApplicable"
+)
+
+// ErrActionNotApplicable 单元不支持该操作（如未配置 ExecReload 的服务执行 reload）
+var ErrActionNotApplicable = errors.New("action not applicable to unit")
+
+// unitTypes systemd 支持的单元类型后缀
+var unitTypes = []string{
+	".service", ".socket", ".target", ".device", ".mount", ".automount",
+	".swap", ".timer", ".path", ".slice", ".scope",
+}
+
+// UnitName 返回完整的单元名，未带类型后缀时补全为 .service
+func UnitName(name string) string {
+	for _, suffix := range unitTypes {
+		if strings.HasSuffix(name, suffix) {
+			return name
+		}
+	}
+	return name + ".service"
+}
+
+// TemplateName 返回实例单元对应的模板单元名，如 worker@1.service 返回 worker@.service
+// 不是模板或实例单元时返回空字符串
+func TemplateName(name string) string {
+	name = UnitName(name)
+	at := strings.Index(name, "@")
+	if at < 0 {
+		return ""
+	}
+	return name[:at+1] + path.Ext(name)
+}
+
+// InstanceName 返回模板的实例单元名，如 InstanceName("worker", "1") 返回 worker@1.service
+func InstanceName(template, instance string) string {
+	return UnitName(template + "@" + instance)
+}
+
+// UnitNotFoundError 单元文件不存在
+type UnitNotFoundError struct {
+	Unit string
+}
+
+func (e *UnitNotFoundError) Error() string {
+	return fmt.Sprintf("unit %s not found", e.Unit)
+}
+
+// IsUnitNotFound 判断错误是否表示单元不存在
+func IsUnitNotFound(err error) bool {
+	var notFound *UnitNotFoundError
+	return errors.As(err, &notFound)
+}
+
+// isDBusError 判断错误是否为指定名称的 D-Bus 错误
+func isDBusError(err error, name string) bool {
+	var dbusErr dbus.Error
+	return errors.As(err, &dbusErr) && dbusErr.Name == name
+}
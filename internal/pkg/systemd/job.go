@@ -0,0 +1,277 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+const jobRemovedMatch = "type='signal',interface='org.freedesktop.systemd1.Manager',member='JobRemoved'"
+
+const (
+	// jobRetention 已完成任务的保留时间
+	jobRetention = time.Hour
+	// unclaimedRetention 未被认领的 JobRemoved 结果保留时间
+	unclaimedRetention = time.Minute
+	// maxUnclaimed 未被认领结果的数量上限，主机上所有任务的 JobRemoved 都会先进入该表
+	maxUnclaimed = 1024
+	// pruneInterval 处理信号时清理过期记录的最小间隔
+	pruneInterval = 10 * time.Second
+)
+
+// JobResult systemd 任务结果
+type JobResult string
+
+const (
+	JobResultDone       JobResult = "done"
+	JobResultFailed     JobResult = "failed"
+	JobResultTimeout    JobResult = "timeout"
+	JobResultCanceled   JobResult = "canceled"
+	JobResultDependency JobResult = "dependency"
+	JobResultSkipped    JobResult = "skipped"
+	// JobResultUnknown 任务在 D-Bus 连接断开期间结束，结果未知
+	JobResultUnknown JobResult = "unknown"
+)
+
+// JobState 任务状态
+type JobState string
+
+const (
+	JobStateRunning  JobState = "running"
+	JobStateFinished JobState = "finished"
+)
+
+// ErrJobNotFound 任务不存在或已过期
+var ErrJobNotFound = errors.New("job not found")
+
+// Job systemd 任务
+type Job struct {
+	ID         string     `json:"id"`
+	Unit       string     `json:"unit"`
+	Action     string     `json:"action"`
+	State      JobState   `json:"state"`
+	Result     JobResult  `json:"result,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Succeeded 任务是否已成功完成
+func (j *Job) Succeeded() bool {
+	return j.State == JobStateFinished && j.Result == JobResultDone
+}
+
+// trackedJob 跟踪中的任务
+type trackedJob struct {
+	job  Job
+	done chan struct{}
+}
+
+// unclaimedResult 在任务注册前就已收到的结果
+type unclaimedResult struct {
+	result     JobResult
+	receivedAt time.Time
+}
+
+// jobTracker 通过 JobRemoved 信号跟踪任务结果
+type jobTracker struct {
+	mu        sync.Mutex
+	jobs      map[dbus.ObjectPath]*trackedJob
+	byID      map[string]dbus.ObjectPath
+	unclaimed map[dbus.ObjectPath]unclaimedResult
+	lastPrune time.Time
+}
+
+func newJobTracker() *jobTracker {
+	return &jobTracker{
+		jobs:      make(map[dbus.ObjectPath]*trackedJob),
+		byID:      make(map[string]dbus.ObjectPath),
+		unclaimed: make(map[dbus.ObjectPath]unclaimedResult),
+	}
+}
+
+// track 开始跟踪任务
+func (t *jobTracker) track(path dbus.ObjectPath, unit, action string) *Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneLocked()
+
+	tj := &trackedJob{
+		job: Job{
+			ID:        jobID(path),
+			Unit:      unit,
+			Action:    action,
+			State:     JobStateRunning,
+			CreatedAt: time.Now(),
+		},
+		done: make(chan struct{}),
+	}
+
+	// JobRemoved 信号可能先于 Start/Stop 调用的返回被处理
+	if res, ok := t.unclaimed[path]; ok {
+		delete(t.unclaimed, path)
+		t.finishLocked(tj, res.result, res.receivedAt)
+	}
+
+	t.jobs[path] = tj
+	t.byID[tj.job.ID] = path
+
+	job := tj.job
+	return &job
+}
+
+// handleSignal 处理 JobRemoved 信号
+func (t *jobTracker) handleSignal(sig *dbus.Signal) {
+	if sig.Name != mngerMethod+".JobRemoved" || len(sig.Body) < 4 {
+		return
+	}
+
+	path, ok := sig.Body[1].(dbus.ObjectPath)
+	if !ok {
+		return
+	}
+	result := JobResult(getString(sig.Body[3]))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// 没有 API 请求时 track 不会被调用，在这里定期清理
+	now := time.Now()
+	if now.Sub(t.lastPrune) > pruneInterval {
+		t.pruneLocked()
+	}
+
+	tj, ok := t.jobs[path]
+	if !ok {
+		if len(t.unclaimed) >= maxUnclaimed {
+			t.evictOldestUnclaimedLocked()
+		}
+		t.unclaimed[path] = unclaimedResult{result: result, receivedAt: now}
+		return
+	}
+	if tj.job.State == JobStateRunning {
+		t.finishLocked(tj, result, time.Now())
+	}
+}
+
+// finishLocked 标记任务完成，调用方需持有锁
+func (t *jobTracker) finishLocked(tj *trackedJob, result JobResult, at time.Time) {
+	tj.job.State = JobStateFinished
+	tj.job.Result = result
+	tj.job.FinishedAt = &at
+	close(tj.done)
+}
+
+// get 获取任务快照
+func (t *jobTracker) get(id string) (*Job, <-chan struct{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	path, ok := t.byID[id]
+	if !ok {
+		return nil, nil, ErrJobNotFound
+	}
+	tj := t.jobs[path]
+	job := tj.job
+	return &job, tj.done, nil
+}
+
+// reconcile 在重新连接后对账，active 为 systemd 中仍在排队或执行的任务
+// 不在其中的运行中任务已在断线期间结束，其 JobRemoved 信号已丢失，以 unknown 结果完成
+func (t *jobTracker) reconcile(active map[dbus.ObjectPath]bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	now := time.Now()
+	for path, tj := range t.jobs {
+		if tj.job.State == JobStateRunning && !active[path] {
+			t.finishLocked(tj, JobResultUnknown, now)
+			n++
+		}
+	}
+	return n
+}
+
+// evictOldestUnclaimedLocked 删除最早收到的未认领结果，调用方需持有锁
+func (t *jobTracker) evictOldestUnclaimedLocked() {
+	var oldest dbus.ObjectPath
+	var oldestAt time.Time
+	for path, res := range t.unclaimed {
+		if oldestAt.IsZero() || res.receivedAt.Before(oldestAt) {
+			oldest, oldestAt = path, res.receivedAt
+		}
+	}
+	delete(t.unclaimed, oldest)
+}
+
+// pruneLocked 清理过期的任务记录，调用方需持有锁
+func (t *jobTracker) pruneLocked() {
+	now := time.Now()
+	t.lastPrune = now
+	for path, tj := range t.jobs {
+		if tj.job.FinishedAt != nil && now.Sub(*tj.job.FinishedAt) > jobRetention {
+			delete(t.jobs, path)
+			delete(t.byID, tj.job.ID)
+		}
+	}
+	for path, res := range t.unclaimed {
+		if now.Sub(res.receivedAt) > unclaimedRetention {
+			delete(t.unclaimed, path)
+		}
+	}
+}
+
+// jobID 从任务对象路径中提取任务ID，如 /org/freedesktop/systemd1/job/1234 -> 1234
+func jobID(path dbus.ObjectPath) string {
+	s := string(path)
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '/' {
+			if _, err := strconv.ParseUint(s[i+1:], 10, 32); err == nil {
+				return s[i+1:]
+			}
+			break
+		}
+	}
+	return s
+}
+
+// GetJob 获取任务当前状态
+func (m *Manager) GetJob(id string) (*Job, error) {
+	job, _, err := m.jobs.get(id)
+	return job, err
+}
+
+// WaitJob 等待任务完成，超时后返回任务当前状态
+func (m *Manager) WaitJob(ctx context.Context, id string, timeout time.Duration) (*Job, error) {
+	_, done, err := m.jobs.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return m.GetJob(id)
+}
+
+// JobError 任务未成功完成
+type JobError struct {
+	Job *Job
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("job %s (%s %s) finished with result %s", e.Job.ID, e.Job.Action, e.Job.Unit, e.Job.Result)
+}
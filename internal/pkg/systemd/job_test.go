@@ -0,0 +1,165 @@
+package systemd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+// jobRemoved 构造 JobRemoved 信号：id、任务路径、单元名、结果
+func jobRemoved(path dbus.ObjectPath, unit string, result JobResult) *dbus.Signal {
+	return &dbus.Signal{
+		Name: mngerMethod + ".JobRemoved",
+		Body: []interface{}{uint32(1), path, unit, string(result)},
+	}
+}
+
+func TestJobID(t *testing.T) {
+	tests := []struct {
+		path dbus.ObjectPath
+		want string
+	}{
+		{"/org/freedesktop/systemd1/job/1234", "1234"},
+		{"/org/freedesktop/systemd1/job/0", "0"},
+		{"/org/freedesktop/systemd1/job/abc", "/org/freedesktop/systemd1/job/abc"},
+		{"1234", "1234"},
+	}
+	for _, tt := range tests {
+		if got := jobID(tt.path); got != tt.want {
+			t.Errorf("jobID(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestJobTrackerFinishesTrackedJob(t *testing.T) {
+	tracker := newJobTracker()
+	path := dbus.ObjectPath("/org/freedesktop/systemd1/job/1")
+
+	job := tracker.track(path, "app.service", "start")
+	if job.State != JobStateRunning {
+		t.Fatalf("state = %s, want running", job.State)
+	}
+
+	tracker.handleSignal(jobRemoved(path, "app.service", JobResultDone))
+
+	got, done, err := tracker.get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Fatal("done channel not closed")
+	}
+	if !got.Succeeded() || got.FinishedAt == nil {
+		t.Fatalf("job = %+v, want finished with done", got)
+	}
+}
+
+func TestJobTrackerClaimsEarlyResult(t *testing.T) {
+	tracker := newJobTracker()
+	path := dbus.ObjectPath("/org/freedesktop/systemd1/job/2")
+
+	// 信号先于 track 到达
+	tracker.handleSignal(jobRemoved(path, "app.service", JobResultFailed))
+	job := tracker.track(path, "app.service", "start")
+
+	if job.State != JobStateFinished || job.Result != JobResultFailed {
+		t.Fatalf("job = %+v, want finished with failed", job)
+	}
+	if len(tracker.unclaimed) != 0 {
+		t.Fatalf("unclaimed = %d, want 0", len(tracker.unclaimed))
+	}
+}
+
+func TestJobTrackerIgnoresOtherSignals(t *testing.T) {
+	tracker := newJobTracker()
+	tests := []*dbus.Signal{
+		{Name: mngerMethod + ".JobNew", Body: []interface{}{uint32(1), dbus.ObjectPath("/job/1"), "a.service", "done"}},
+		{Name: mngerMethod + ".JobRemoved", Body: []interface{}{uint32(1)}},
+		{Name: mngerMethod + ".JobRemoved", Body: []interface{}{uint32(1), "not-a-path", "a.service", "done"}},
+	}
+	for _, sig := range tests {
+		tracker.handleSignal(sig)
+	}
+	if len(tracker.unclaimed) != 0 {
+		t.Fatalf("unclaimed = %d, want 0", len(tracker.unclaimed))
+	}
+}
+
+func TestJobTrackerCapsUnclaimed(t *testing.T) {
+	tracker := newJobTracker()
+	for i := 0; i < maxUnclaimed+10; i++ {
+		path := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/systemd1/job/%d", i))
+		tracker.handleSignal(jobRemoved(path, "other.service", JobResultDone))
+	}
+	if len(tracker.unclaimed) != maxUnclaimed {
+		t.Fatalf("unclaimed = %d, want %d", len(tracker.unclaimed), maxUnclaimed)
+	}
+}
+
+func TestJobTrackerPrune(t *testing.T) {
+	tracker := newJobTracker()
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		path     dbus.ObjectPath
+		finished *time.Time
+		kept     bool
+	}{
+		{"running", "/org/freedesktop/systemd1/job/1", nil, true},
+		{"recent", "/org/freedesktop/systemd1/job/2", timePtr(now.Add(-time.Minute)), true},
+		{"expired", "/org/freedesktop/systemd1/job/3", timePtr(now.Add(-2 * jobRetention)), false},
+	}
+	for _, tt := range tests {
+		tracker.track(tt.path, "app.service", "start")
+		tracker.jobs[tt.path].job.FinishedAt = tt.finished
+	}
+	tracker.unclaimed["/org/freedesktop/systemd1/job/8"] = unclaimedResult{result: JobResultDone, receivedAt: now}
+	tracker.unclaimed["/org/freedesktop/systemd1/job/9"] = unclaimedResult{result: JobResultDone, receivedAt: now.Add(-2 * unclaimedRetention)}
+
+	tracker.mu.Lock()
+	tracker.pruneLocked()
+	tracker.mu.Unlock()
+
+	for _, tt := range tests {
+		_, _, err := tracker.get(jobID(tt.path))
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s: kept = %v, want %v", tt.name, kept, tt.kept)
+		}
+	}
+	if _, ok := tracker.unclaimed["/org/freedesktop/systemd1/job/8"]; !ok {
+		t.Error("recent unclaimed result was pruned")
+	}
+	if _, ok := tracker.unclaimed["/org/freedesktop/systemd1/job/9"]; ok {
+		t.Error("expired unclaimed result was kept")
+	}
+}
+
+func TestJobTrackerReconcile(t *testing.T) {
+	tracker := newJobTracker()
+	active := dbus.ObjectPath("/org/freedesktop/systemd1/job/1")
+	lost := dbus.ObjectPath("/org/freedesktop/systemd1/job/2")
+	tracker.track(active, "a.service", "start")
+	tracker.track(lost, "b.service", "stop")
+
+	if n := tracker.reconcile(map[dbus.ObjectPath]bool{active: true}); n != 1 {
+		t.Fatalf("reconcile = %d, want 1", n)
+	}
+
+	job, _, _ := tracker.get("1")
+	if job.State != JobStateRunning {
+		t.Errorf("active job state = %s, want running", job.State)
+	}
+	job, _, _ = tracker.get("2")
+	if job.State != JobStateFinished || job.Result != JobResultUnknown {
+		t.Errorf("lost job = %+v, want finished with unknown", job)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,73 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// KillTarget KillUnit 发送信号的目标进程
+type KillTarget string
+
+const (
+	KillMain    KillTarget = "main"    // 仅主进程
+	KillControl KillTarget = "control" // 仅控制进程（如 ExecReload、ExecStop）
+	KillAll     KillTarget = "all"     // 控制组内的所有进程
+)
+
+var (
+	// ErrInvalidSignal 无法识别的信号
+	ErrInvalidSignal = errors.New("invalid signal")
+	// ErrInvalidKillTarget 无法识别的信号目标
+	ErrInvalidKillTarget = errors.New("invalid kill target, must be one of main, control, all")
+)
+
+// ParseSignal 解析信号名（SIGTERM、TERM）或信号值（15）
+func ParseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n <= 0 || unix.SignalName(syscall.Signal(n)) == "" {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidSignal, s)
+		}
+		return syscall.Signal(n), nil
+	}
+
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidSignal, s)
+	}
+	return sig, nil
+}
+
+// ParseKillTarget 解析信号目标，为空时默认为 all
+func ParseKillTarget(s string) (KillTarget, error) {
+	switch target := KillTarget(s); target {
+	case "":
+		return KillAll, nil
+	case KillMain, KillControl, KillAll:
+		return target, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidKillTarget, s)
+	}
+}
+
+// KillUnit 向单元的进程发送信号
+func (m *Manager) KillUnit(ctx context.Context, serviceName string, target KillTarget, signal syscall.Signal) error {
+	name := UnitName(serviceName)
+	_, err := m.call(ctx, objectPath, mngerMethod+".KillUnit", name, string(target), int32(signal))
+	if isDBusError(err, errNoSuchUnit) {
+		return &UnitNotFoundError{Unit: name}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to kill unit %s: %w", name, err)
+	}
+	return nil
+}
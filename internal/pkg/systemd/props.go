@@ -0,0 +1,51 @@
+package systemd
+
+import (
+	"math"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+// propString 从属性表中读取字符串属性
+func propString(props map[string]dbus.Variant, name string) string {
+	v, _ := props[name].Value().(string)
+	return v
+}
+
+// propStrings 从属性表中读取字符串数组属性
+func propStrings(props map[string]dbus.Variant, name string) []string {
+	v, _ := props[name].Value().([]string)
+	return v
+}
+
+// propUint32 从属性表中读取 uint32 属性
+func propUint32(props map[string]dbus.Variant, name string) uint32 {
+	v, _ := props[name].Value().(uint32)
+	return v
+}
+
+// propInt32 从属性表中读取 int32 属性
+func propInt32(props map[string]dbus.Variant, name string) int32 {
+	v, _ := props[name].Value().(int32)
+	return v
+}
+
+// propOptionalUint64 读取 uint64 属性，systemd 使用 UINT64_MAX 表示不可用
+func propOptionalUint64(props map[string]dbus.Variant, name string) *uint64 {
+	v, ok := props[name].Value().(uint64)
+	if !ok || v == math.MaxUint64 {
+		return nil
+	}
+	return &v
+}
+
+// propTimestamp 读取微秒时间戳属性，0 表示从未发生
+func propTimestamp(props map[string]dbus.Variant, name string) *time.Time {
+	v, ok := props[name].Value().(uint64)
+	if !ok || v == 0 {
+		return nil
+	}
+	t := time.UnixMicro(int64(v)).UTC()
+	return &t
+}
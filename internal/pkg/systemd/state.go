@@ -0,0 +1,138 @@
+package systemd
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+const propertiesChangedMatch = "type='signal',sender='org.freedesktop.systemd1',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged',arg0='org.freedesktop.systemd1.Unit'"
+
+const unitRemovedMatch = "type='signal',interface='org.freedesktop.systemd1.Manager',member='UnitRemoved'"
+
+const unitPathPrefix = "/org/freedesktop/systemd1/unit/"
+
+// UnitStateChange 单元状态变化
+type UnitStateChange struct {
+	Unit           string    `json:"unit"`
+	ActiveState    string    `json:"active_state"`
+	SubState       string    `json:"sub_state"`
+	PreviousActive string    `json:"previous_active_state,omitempty"`
+	PreviousSub    string    `json:"previous_sub_state,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// unitState 单元的 ActiveState/SubState
+type unitState struct {
+	active string
+	sub    string
+}
+
+// stateWatcher 通过 PropertiesChanged 信号跟踪单元状态变化
+type stateWatcher struct {
+	mu        sync.Mutex
+	states    map[string]unitState
+	listeners []func(UnitStateChange)
+}
+
+func newStateWatcher() *stateWatcher {
+	return &stateWatcher{
+		states: make(map[string]unitState),
+	}
+}
+
+// handleSignal 处理 PropertiesChanged 信号
+func (w *stateWatcher) handleSignal(sig *dbus.Signal) {
+	if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) < 2 {
+		return
+	}
+	if iface, _ := sig.Body[0].(string); iface != destUnit {
+		return
+	}
+	if !strings.HasPrefix(string(sig.Path), unitPathPrefix) {
+		return
+	}
+
+	changed, ok := sig.Body[1].(map[string]dbus.Variant)
+	if !ok {
+		return
+	}
+	activeVar, hasActive := changed["ActiveState"]
+	subVar, hasSub := changed["SubState"]
+	if !hasActive && !hasSub {
+		return
+	}
+
+	unit := unescapeUnitPath(strings.TrimPrefix(string(sig.Path), unitPathPrefix))
+
+	w.mu.Lock()
+	prev := w.states[unit]
+	next := prev
+	if hasActive {
+		next.active, _ = activeVar.Value().(string)
+	}
+	if hasSub {
+		next.sub, _ = subVar.Value().(string)
+	}
+	if next == prev {
+		w.mu.Unlock()
+		return
+	}
+	w.states[unit] = next
+	listeners := append([]func(UnitStateChange){}, w.listeners...)
+	w.mu.Unlock()
+
+	change := UnitStateChange{
+		Unit:           unit,
+		ActiveState:    next.active,
+		SubState:       next.sub,
+		PreviousActive: prev.active,
+		PreviousSub:    prev.sub,
+		Timestamp:      time.Now().UTC(),
+	}
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
+
+// handleUnitRemoved 处理 UnitRemoved 信号，单元被 systemd 回收（如临时的 run-*.service 和 scope 单元）后不再记录其状态
+func (w *stateWatcher) handleUnitRemoved(sig *dbus.Signal) {
+	if sig.Name != mngerMethod+".UnitRemoved" || len(sig.Body) < 1 {
+		return
+	}
+	unit, ok := sig.Body[0].(string)
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+	delete(w.states, unit)
+	w.mu.Unlock()
+}
+
+// unescapeUnitPath 还原 systemd 对象路径中转义的单元名，如 my_2dapp_2eservice -> my-app.service
+func unescapeUnitPath(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' && i+2 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// OnUnitStateChange 注册单元状态变化监听器
+// 监听器在信号分发协程中同步调用，不应阻塞
+func (m *Manager) OnUnitStateChange(listener func(UnitStateChange)) {
+	m.states.mu.Lock()
+	defer m.states.mu.Unlock()
+	m.states.listeners = append(m.states.listeners, listener)
+}
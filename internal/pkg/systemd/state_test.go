@@ -0,0 +1,100 @@
+package systemd
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus"
+)
+
+// propertiesChanged 构造单元的 PropertiesChanged 信号
+func propertiesChanged(escaped string, changed map[string]dbus.Variant) *dbus.Signal {
+	return &dbus.Signal{
+		Path: dbus.ObjectPath(unitPathPrefix + escaped),
+		Name: "org.freedesktop.DBus.Properties.PropertiesChanged",
+		Body: []interface{}{destUnit, changed, []string{}},
+	}
+}
+
+func TestUnescapeUnitPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"my_2dapp_2eservice", "my-app.service"},
+		{"worker_401_2eservice", "worker@1.service"},
+		{"plain", "plain"},
+		{"trailing_", "trailing_"},
+		{"bad_zzescape", "bad_zzescape"},
+	}
+	for _, tt := range tests {
+		if got := unescapeUnitPath(tt.in); got != tt.want {
+			t.Errorf("unescapeUnitPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStateWatcherNotifiesChanges(t *testing.T) {
+	w := newStateWatcher()
+	var changes []UnitStateChange
+	w.listeners = append(w.listeners, func(c UnitStateChange) { changes = append(changes, c) })
+
+	w.handleSignal(propertiesChanged("app_2eservice", map[string]dbus.Variant{
+		"ActiveState": dbus.MakeVariant("activating"),
+		"SubState":    dbus.MakeVariant("start"),
+	}))
+	w.handleSignal(propertiesChanged("app_2eservice", map[string]dbus.Variant{
+		"ActiveState": dbus.MakeVariant("active"),
+		"SubState":    dbus.MakeVariant("running"),
+	}))
+	// 状态未变化时不通知
+	w.handleSignal(propertiesChanged("app_2eservice", map[string]dbus.Variant{
+		"ActiveState": dbus.MakeVariant("active"),
+	}))
+	// 不含状态属性的变化不通知
+	w.handleSignal(propertiesChanged("app_2eservice", map[string]dbus.Variant{
+		"Description": dbus.MakeVariant("x"),
+	}))
+
+	if len(changes) != 2 {
+		t.Fatalf("changes = %d, want 2", len(changes))
+	}
+	last := changes[1]
+	if last.Unit != "app.service" || last.ActiveState != "active" || last.SubState != "running" ||
+		last.PreviousActive != "activating" || last.PreviousSub != "start" {
+		t.Fatalf("change = %+v", last)
+	}
+}
+
+func TestStateWatcherIgnoresOtherSignals(t *testing.T) {
+	w := newStateWatcher()
+	changed := map[string]dbus.Variant{"ActiveState": dbus.MakeVariant("active")}
+	tests := []*dbus.Signal{
+		{Path: unitPathPrefix + "a_2eservice", Name: "org.freedesktop.DBus.Properties.Other", Body: []interface{}{destUnit, changed}},
+		{Path: unitPathPrefix + "a_2eservice", Name: "org.freedesktop.DBus.Properties.PropertiesChanged", Body: []interface{}{destService, changed}},
+		{Path: "/org/freedesktop/systemd1/job/1", Name: "org.freedesktop.DBus.Properties.PropertiesChanged", Body: []interface{}{destUnit, changed}},
+		{Path: unitPathPrefix + "a_2eservice", Name: "org.freedesktop.DBus.Properties.PropertiesChanged", Body: []interface{}{destUnit}},
+	}
+	for _, sig := range tests {
+		w.handleSignal(sig)
+	}
+	if len(w.states) != 0 {
+		t.Fatalf("states = %v, want empty", w.states)
+	}
+}
+
+func TestStateWatcherForgetsRemovedUnits(t *testing.T) {
+	w := newStateWatcher()
+	w.handleSignal(propertiesChanged("run_2d1_2eservice", map[string]dbus.Variant{
+		"ActiveState": dbus.MakeVariant("active"),
+	}))
+	if _, ok := w.states["run-1.service"]; !ok {
+		t.Fatal("state not recorded")
+	}
+
+	w.handleUnitRemoved(&dbus.Signal{
+		Name: mngerMethod + ".UnitRemoved",
+		Body: []interface{}{"run-1.service", dbus.ObjectPath(unitPathPrefix + "run_2d1_2eservice")},
+	})
+	if len(w.states) != 0 {
+		t.Fatalf("states = %v, want empty", w.states)
+	}
+}
@@ -3,6 +3,8 @@ package systemd
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/godbus/dbus"
 )
@@ -10,113 +12,183 @@ import (
 const destBus = "org.freedesktop.systemd1"
 const objectPath = "/org/freedesktop/systemd1"
 const getMethod = "org.freedesktop.DBus.Properties.Get"
+const getAllMethod = "org.freedesktop.DBus.Properties.GetAll"
 const mngerMethod = "org.freedesktop.systemd1.Manager"
 const destUnit = "org.freedesktop.systemd1.Unit"
 const destService = "org.freedesktop.systemd1.Service"
 
 // Load unit from systemd
-func Load(serviceName string) (*Unit, error) {
-	conn, err := dbus.SystemBus()
+// 单元未被加载（如已被 systemd 回收）时回退到 LoadUnit；单元文件不存在时返回 *UnitNotFoundError
+func (m *Manager) Load(ctx context.Context, serviceName string) (*Unit, error) {
+	path, err := m.unitPath(ctx, serviceName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to system bus: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	var path dbus.ObjectPath
-	err = conn.Object(destBus, objectPath).Call(mngerMethod+".GetUnit", 0, serviceName).Store(&path)
+	unitProps, err := m.getAllProperties(ctx, path, destUnit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object path: %w", err)
+		return nil, fmt.Errorf("failed to get unit properties: %w", err)
 	}
 
-	var desc string
-	err = conn.Object(destBus, path).Call(getMethod, 0, destUnit, "Description").Store(&desc)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get description: %w", err)
+	// LoadUnit 对不存在的单元也会返回对象，仅 LoadState 为 not-found
+	// 单元文件被删除但进程仍在运行时 ActiveState 不是 inactive，此时仍返回状态
+	if propString(unitProps, "LoadState") == "not-found" && propString(unitProps, "ActiveState") == "inactive" {
+		return nil, &UnitNotFoundError{Unit: UnitName(serviceName)}
 	}
 
-	var loadStatus string
-	err = conn.Object(destBus, path).Call(getMethod, 0, destUnit, "LoadState").Store(&loadStatus)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get load state: %w", err)
+	u := &Unit{
+		Name:                  propString(unitProps, "Id"),
+		Service:               serviceName,
+		Description:           propString(unitProps, "Description"),
+		LoadState:             propString(unitProps, "LoadState"),
+		ActiveState:           propString(unitProps, "ActiveState"),
+		SubState:              propString(unitProps, "SubState"),
+		UnitFileState:         propString(unitProps, "UnitFileState"),
+		ActiveEnterTimestamp:  propTimestamp(unitProps, "ActiveEnterTimestamp"),
+		InactiveExitTimestamp: propTimestamp(unitProps, "InactiveExitTimestamp"),
+		FragmentPath:          propString(unitProps, "FragmentPath"),
+		DropInPaths:           propStrings(unitProps, "DropInPaths"),
 	}
 
-	var activeStatus string
-	err = conn.Object(destBus, path).Call(getMethod, 0, destUnit, "ActiveState").Store(&activeStatus)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get active state: %w", err)
+	// 只有 service 类型的单元才有 Service 接口
+	if !strings.HasSuffix(u.Name, ".service") {
+		return u, nil
 	}
 
-	var unitFileState string
-	err = conn.Object(destBus, path).Call(getMethod, 0, destUnit, "UnitFileState").Store(&unitFileState)
+	serviceProps, err := m.getAllProperties(ctx, path, destService)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get unit file status: %w", err)
+		return nil, fmt.Errorf("failed to get service properties: %w", err)
 	}
 
-	var pid int
-	err = conn.Object(destBus, path).Call(getMethod, 0, destService, "MainPID").Store(&pid)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get main pid: %w", err)
-	}
+	u.PID = int(propUint32(serviceProps, "MainPID"))
+	u.Result = propString(serviceProps, "Result")
+	u.MemoryCurrent = propOptionalUint64(serviceProps, "MemoryCurrent")
+	u.CPUUsageNSec = propOptionalUint64(serviceProps, "CPUUsageNSec")
+	u.TasksCurrent = propOptionalUint64(serviceProps, "TasksCurrent")
+	u.NRestarts = propUint32(serviceProps, "NRestarts")
+	u.ExecMainStatus = propInt32(serviceProps, "ExecMainStatus")
+	u.ExecMainCode = propInt32(serviceProps, "ExecMainCode")
 
-	u := &Unit{
-		Service:       serviceName,
-		Description:   desc,
-		LoadState:     loadStatus,
-		ActiveState:   activeStatus,
-		UnitFileState: unitFileState,
-		PID:           pid,
+	return u, nil
+}
+
+// unitPath 获取单元的对象路径，未加载的单元通过 LoadUnit 加载
+func (m *Manager) unitPath(ctx context.Context, serviceName string) (dbus.ObjectPath, error) {
+	name := UnitName(serviceName)
+
+	var path dbus.ObjectPath
+	call, err := m.call(ctx, objectPath, mngerMethod+".GetUnit", name)
+	if err == nil {
+		err = call.Store(&path)
+	}
+	if err == nil {
+		return path, nil
+	}
+	if !isDBusError(err, errNoSuchUnit) {
+		return "", fmt.Errorf("failed to get object path: %w", err)
 	}
 
-	return u, nil
+	call, err = m.call(ctx, objectPath, mngerMethod+".LoadUnit", name)
+	if err == nil {
+		err = call.Store(&path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load unit: %w", err)
+	}
+	return path, nil
 }
 
-// Send an action to systemd
-func Send(serviceName string, action string, mode string) error {
-	conn, err := dbus.SystemBus()
+// getProperty 读取单元对象上的属性
+func (m *Manager) getProperty(ctx context.Context, path dbus.ObjectPath, iface, name string, value interface{}) error {
+	call, err := m.call(ctx, path, getMethod, iface, name)
 	if err != nil {
-		return fmt.Errorf("failed to connect to system bus: %w", err)
+		return err
 	}
-	defer conn.Close()
+	return call.Store(value)
+}
 
-	var path dbus.ObjectPath
-	obj := conn.Object(destBus, objectPath)
+// getAllProperties 一次性读取对象在指定接口上的全部属性
+func (m *Manager) getAllProperties(ctx context.Context, path dbus.ObjectPath, iface string) (map[string]dbus.Variant, error) {
+	call, err := m.call(ctx, path, getAllMethod, iface)
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[string]dbus.Variant)
+	if err := call.Store(&props); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
 
+// Send an action to systemd
+// 返回 systemd 创建的任务，可通过 WaitJob 等待其结果
+func (m *Manager) Send(ctx context.Context, serviceName string, action string, mode string) (*Job, error) {
+	var method string
 	switch action {
 	case "start":
-		err = obj.Call(mngerMethod+".StartUnit", 0, serviceName, mode).Store(&path)
+		method = mngerMethod + ".StartUnit"
 	case "restart":
-		err = obj.Call(mngerMethod+".RestartUnit", 0, serviceName, mode).Store(&path)
+		method = mngerMethod + ".RestartUnit"
 	case "stop":
-		err = obj.Call(mngerMethod+".StopUnit", 0, serviceName, mode).Store(&path)
+		method = mngerMethod + ".StopUnit"
 	case "reload":
-		err = obj.Call(mngerMethod+".ReloadUnit", 0, serviceName, mode).Store(&path)
+		method = mngerMethod + ".ReloadUnit"
+	case "reload-or-restart":
+		method = mngerMethod + ".ReloadOrRestartUnit"
 	default:
-		return fmt.Errorf("unknown action: %s", action)
+		return nil, fmt.Errorf("unknown action: %s", action)
+	}
+
+	name := UnitName(serviceName)
+
+	var path dbus.ObjectPath
+	call, err := m.call(ctx, objectPath, method, name, mode)
+	if err == nil {
+		err = call.Store(&path)
+	}
+	if isDBusError(err, errNoSuchUnit) {
+		return nil, &UnitNotFoundError{Unit: name}
+	}
+	if isDBusError(err, errJobTypeNotApplicable) {
+		return nil, fmt.Errorf("%w: %s on %s", ErrActionNotApplicable, action, name)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to execute action %s on service %s: %w", action, serviceName, err)
+		return nil, fmt.Errorf("failed to execute action %s on service %s: %w", action, serviceName, err)
 	}
-	return nil
+	return m.jobs.track(path, name, action), nil
 }
 
-// EnableUnit 启用服务单元
-func EnableUnit(serviceName string) error {
-	conn, err := dbus.SystemBus()
+// SendAndWait 发送操作并等待任务完成
+// 任务未以 done 结束时返回 *JobError；等待超时则返回仍在运行的任务
+func (m *Manager) SendAndWait(ctx context.Context, serviceName, action, mode string, timeout time.Duration) (*Job, error) {
+	job, err := m.Send(ctx, serviceName, action, mode)
 	if err != nil {
-		return fmt.Errorf("failed to connect to system bus: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	obj := conn.Object(destBus, objectPath)
+	job, err = m.WaitJob(ctx, job.ID, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if job.State == JobStateFinished && job.Result != JobResultDone {
+		return job, &JobError{Job: job}
+	}
+	return job, nil
+}
 
+// EnableUnit 启用服务单元
+// runtime 为 true 时启用链接写入 /run，重启系统后失效
+func (m *Manager) EnableUnit(ctx context.Context, serviceName string, runtime bool) error {
 	// EnableUnitFiles 方法的参数: files, runtime, force
-	files := []string{serviceName}
-	runtime := false
+	files := []string{UnitName(serviceName)}
 	force := false
 
 	var carries bool
 	var changes []interface{}
-	err = obj.Call(mngerMethod+".EnableUnitFiles", 0, files, runtime, force).Store(&carries, &changes)
+	call, err := m.call(ctx, objectPath, mngerMethod+".EnableUnitFiles", files, runtime, force)
+	if err == nil {
+		err = call.Store(&carries, &changes)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to enable unit %s: %w", serviceName, err)
 	}
@@ -124,40 +196,76 @@ func EnableUnit(serviceName string) error {
 	return nil
 }
 
-// DisableUnit 禁用服务单元
-func DisableUnit(serviceName string) error {
-	conn, err := dbus.SystemBus()
+// DisableUnit 禁用服务单元，runtime 需与启用时一致
+func (m *Manager) DisableUnit(ctx context.Context, serviceName string, runtime bool) error {
+	// DisableUnitFiles 方法的参数: files, runtime
+	files := []string{UnitName(serviceName)}
+
+	var changes []interface{}
+	call, err := m.call(ctx, objectPath, mngerMethod+".DisableUnitFiles", files, runtime)
+	if err == nil {
+		err = call.Store(&changes)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to connect to system bus: %w", err)
+		return fmt.Errorf("failed to disable unit %s: %w", serviceName, err)
 	}
-	defer conn.Close()
 
-	obj := conn.Object(destBus, objectPath)
+	return nil
+}
 
-	// DisableUnitFiles 方法的参数: files, runtime
-	files := []string{serviceName}
-	runtime := false
+// MaskUnit 屏蔽单元，使其无法被启动
+// runtime 为 true 时屏蔽链接写入 /run，重启系统后失效
+func (m *Manager) MaskUnit(ctx context.Context, serviceName string, runtime bool) error {
+	// MaskUnitFiles 方法的参数: files, runtime, force
+	files := []string{UnitName(serviceName)}
+	force := false
 
 	var changes []interface{}
-	err = obj.Call(mngerMethod+".DisableUnitFiles", 0, files, runtime).Store(&changes)
+	call, err := m.call(ctx, objectPath, mngerMethod+".MaskUnitFiles", files, runtime, force)
+	if err == nil {
+		err = call.Store(&changes)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to disable unit %s: %w", serviceName, err)
+		return fmt.Errorf("failed to mask unit %s: %w", serviceName, err)
 	}
 
 	return nil
 }
 
-// ReloadDaemon 重新加载systemd守护进程
-func ReloadDaemon() error {
-	conn, err := dbus.SystemBus()
+// UnmaskUnit 取消单元屏蔽
+func (m *Manager) UnmaskUnit(ctx context.Context, serviceName string, runtime bool) error {
+	// UnmaskUnitFiles 方法的参数: files, runtime
+	files := []string{UnitName(serviceName)}
+
+	var changes []interface{}
+	call, err := m.call(ctx, objectPath, mngerMethod+".UnmaskUnitFiles", files, runtime)
+	if err == nil {
+		err = call.Store(&changes)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to connect to system bus: %w", err)
+		return fmt.Errorf("failed to unmask unit %s: %w", serviceName, err)
 	}
-	defer conn.Close()
 
-	obj := conn.Object(destBus, objectPath)
-	err = obj.Call(mngerMethod+".Reload", 0).Err
+	return nil
+}
+
+// SetUnitProperties 修改运行中单元的属性
+// runtime 为 true 时仅在本次启动内生效，否则 systemd 会将修改持久化为 drop-in
+func (m *Manager) SetUnitProperties(ctx context.Context, serviceName string, runtime bool, properties []Property) error {
+	name := UnitName(serviceName)
+	_, err := m.call(ctx, objectPath, mngerMethod+".SetUnitProperties", name, runtime, properties)
+	if isDBusError(err, errNoSuchUnit) {
+		return &UnitNotFoundError{Unit: name}
+	}
 	if err != nil {
+		return fmt.Errorf("failed to set properties of unit %s: %w", name, err)
+	}
+	return nil
+}
+
+// ReloadDaemon 重新加载systemd守护进程
+func (m *Manager) ReloadDaemon(ctx context.Context) error {
+	if _, err := m.call(ctx, objectPath, mngerMethod+".Reload"); err != nil {
 		return fmt.Errorf("failed to reload systemd daemon: %w", err)
 	}
 
@@ -165,8 +273,8 @@ func ReloadDaemon() error {
 }
 
 // GetServiceStatusText 获取服务状态文本（类似systemctl status输出）
-func GetServiceStatusText(serviceName string) (string, error) {
-	unit, err := Load(serviceName)
+func (m *Manager) GetServiceStatusText(ctx context.Context, serviceName string) (string, error) {
+	unit, err := m.Load(ctx, serviceName)
 	if err != nil {
 		return "", err
 	}
@@ -185,19 +293,10 @@ func GetServiceStatusText(serviceName string) (string, error) {
 }
 
 // CheckSystemdAvailable 检查systemd是否可用
-func CheckSystemdAvailable() error {
-	conn, err := dbus.SystemBus()
-	if err != nil {
-		return fmt.Errorf("failed to connect to system bus: %w", err)
-	}
-	defer conn.Close()
-
-	obj := conn.Object(destBus, objectPath)
-
+func (m *Manager) CheckSystemdAvailable(ctx context.Context) error {
 	// 尝试获取systemd版本
 	var version string
-	err = obj.Call("org.freedesktop.DBus.Properties.Get", 0, mngerMethod, "Version").Store(&version)
-	if err != nil {
+	if err := m.getProperty(ctx, objectPath, mngerMethod, "Version", &version); err != nil {
 		return fmt.Errorf("systemd not available: %w", err)
 	}
 
@@ -205,18 +304,11 @@ func CheckSystemdAvailable() error {
 }
 
 // ListUnits 获取所有systemd单元列表
-func ListUnits(ctx context.Context) ([]*Unit, error) {
-	conn, err := dbus.SystemBus()
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to system bus: %w", err)
-	}
-	defer conn.Close()
-
+func (m *Manager) ListUnits(ctx context.Context) ([]*Unit, error) {
 	// 调用ListUnits方法获取所有单元
-	obj := conn.Object(destBus, objectPath)
-	call := obj.Call(mngerMethod+".ListUnits", 0)
-	if call.Err != nil {
-		return nil, fmt.Errorf("failed to list units: %w", call.Err)
+	call, err := m.call(ctx, objectPath, mngerMethod+".ListUnits")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list units: %w", err)
 	}
 
 	// 解析返回的单元数据
@@ -0,0 +1,86 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const destTimer = "org.freedesktop.systemd1.Timer"
+
+// Timer 定时器单元信息
+type Timer struct {
+	Name          string     `json:"name"`
+	Unit          string     `json:"unit"` // 定时器触发的单元
+	Description   string     `json:"description"`
+	LoadState     string     `json:"load_state"`
+	ActiveState   string     `json:"active_state"`
+	SubState      string     `json:"sub_state"`
+	UnitFileState string     `json:"unit_file_state"`
+	Result        string     `json:"result,omitempty"`
+	NextElapse    *time.Time `json:"next_elapse,omitempty"`
+	LastTrigger   *time.Time `json:"last_trigger,omitempty"`
+}
+
+// LoadTimer 读取定时器单元及其下次、上次触发时间
+func (m *Manager) LoadTimer(ctx context.Context, timerName string) (*Timer, error) {
+	path, err := m.unitPath(ctx, timerName)
+	if err != nil {
+		return nil, err
+	}
+
+	unitProps, err := m.getAllProperties(ctx, path, destUnit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit properties: %w", err)
+	}
+	if propString(unitProps, "LoadState") == "not-found" && propString(unitProps, "ActiveState") == "inactive" {
+		return nil, &UnitNotFoundError{Unit: timerName}
+	}
+
+	timerProps, err := m.getAllProperties(ctx, path, destTimer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timer properties: %w", err)
+	}
+
+	t := &Timer{
+		Name:          propString(unitProps, "Id"),
+		Unit:          propString(timerProps, "Unit"),
+		Description:   propString(unitProps, "Description"),
+		LoadState:     propString(unitProps, "LoadState"),
+		ActiveState:   propString(unitProps, "ActiveState"),
+		SubState:      propString(unitProps, "SubState"),
+		UnitFileState: propString(unitProps, "UnitFileState"),
+		Result:        propString(timerProps, "Result"),
+		LastTrigger:   propTimestamp(timerProps, "LastTriggerUSec"),
+	}
+
+	// 下次触发时间取日历时间和单调时间中较早的一个
+	next := propTimestamp(timerProps, "NextElapseUSecRealtime")
+	if mono := monotonicToRealtime(timerProps["NextElapseUSecMonotonic"].Value()); mono != nil {
+		if next == nil || mono.Before(*next) {
+			next = mono
+		}
+	}
+	t.NextElapse = next
+
+	return t, nil
+}
+
+// monotonicToRealtime 将 CLOCK_MONOTONIC 微秒时间戳换算为实际时间
+func monotonicToRealtime(v interface{}) *time.Time {
+	usec, ok := v.(uint64)
+	if !ok || usec == 0 || usec == math.MaxUint64 {
+		return nil
+	}
+
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return nil
+	}
+	nowMono := time.Duration(ts.Nano())
+	t := time.Now().Add(time.Duration(usec)*time.Microsecond - nowMono).UTC()
+	return &t
+}
@@ -0,0 +1,146 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+// Property 单元属性，对应 StartTransientUnit/SetUnitProperties 的 a(sv) 参数
+type Property struct {
+	Name  string
+	Value dbus.Variant
+}
+
+// execCommand 对应 ExecStart 等属性的 (sasb) 结构：路径、参数列表、是否忽略失败
+type execCommand struct {
+	Path          string
+	Args          []string
+	IgnoreFailure bool
+}
+
+// filterList 对应 RestrictAddressFamilies、SystemCallFilter 等属性的 (bas) 结构
+type filterList struct {
+	AllowList bool
+	Values    []string
+}
+
+// environmentFile 对应 EnvironmentFile 属性的 (sb) 结构：路径、文件不存在时是否忽略
+type environmentFile struct {
+	Path          string
+	IgnoreMissing bool
+}
+
+// auxUnit 对应 StartTransientUnit 的辅助单元参数 (sa(sv))
+type auxUnit struct {
+	Name       string
+	Properties []Property
+}
+
+// PropString 创建字符串属性
+func PropString(name, value string) Property {
+	return Property{Name: name, Value: dbus.MakeVariant(value)}
+}
+
+// PropStrings 创建字符串数组属性
+func PropStrings(name string, values []string) Property {
+	return Property{Name: name, Value: dbus.MakeVariant(values)}
+}
+
+// PropBool 创建布尔属性
+func PropBool(name string, value bool) Property {
+	return Property{Name: name, Value: dbus.MakeVariant(value)}
+}
+
+// PropUint64 创建无符号整数属性
+func PropUint64(name string, value uint64) Property {
+	return Property{Name: name, Value: dbus.MakeVariant(value)}
+}
+
+// PropDuration 创建以微秒表示的时长属性（如 TimeoutStartUSec）
+func PropDuration(name string, d time.Duration) Property {
+	return Property{Name: name, Value: dbus.MakeVariant(uint64(d / time.Microsecond))}
+}
+
+// PropFilter 创建 (bas) 结构的列表属性（如 RestrictAddressFamilies、SystemCallFilter）
+// allowList 为 false 时列表中的项被禁止，对应单元文件中的 ~ 前缀
+func PropFilter(name string, allowList bool, values []string) Property {
+	return Property{Name: name, Value: dbus.MakeVariant(filterList{AllowList: allowList, Values: values})}
+}
+
+// PropEnvironmentFiles 创建 EnvironmentFile 属性，"-" 前缀表示文件可以不存在
+func PropEnvironmentFiles(files []string) Property {
+	list := make([]environmentFile, 0, len(files))
+	for _, file := range files {
+		list = append(list, environmentFile{Path: strings.TrimPrefix(file, "-"), IgnoreMissing: strings.HasPrefix(file, "-")})
+	}
+	return Property{Name: "EnvironmentFile", Value: dbus.MakeVariant(list)}
+}
+
+// PropCapabilities 创建能力集属性（如 CapabilityBoundingSet），第一项带 ~ 前缀时保留未列出的能力
+func PropCapabilities(name string, capabilities []string) (Property, error) {
+	var mask uint64
+	invert := len(capabilities) > 0 && strings.HasPrefix(capabilities[0], "~")
+	for _, capability := range capabilities {
+		bit, ok := capabilityBits[strings.TrimPrefix(capability, "~")]
+		if !ok {
+			return Property{}, fmt.Errorf("unknown capability %s", capability)
+		}
+		mask |= 1 << bit
+	}
+	if invert {
+		mask = ^mask
+	}
+	return PropUint64(name, mask), nil
+}
+
+// capabilityBits 能力名对应的位，见 linux/capability.h
+var capabilityBits = map[string]uint{
+	"CAP_CHOWN": 0, "CAP_DAC_OVERRIDE": 1, "CAP_DAC_READ_SEARCH": 2, "CAP_FOWNER": 3, "CAP_FSETID": 4,
+	"CAP_KILL": 5, "CAP_SETGID": 6, "CAP_SETUID": 7, "CAP_SETPCAP": 8, "CAP_LINUX_IMMUTABLE": 9,
+	"CAP_NET_BIND_SERVICE": 10, "CAP_NET_BROADCAST": 11, "CAP_NET_ADMIN": 12, "CAP_NET_RAW": 13,
+	"CAP_IPC_LOCK": 14, "CAP_IPC_OWNER": 15, "CAP_SYS_MODULE": 16, "CAP_SYS_RAWIO": 17, "CAP_SYS_CHROOT": 18,
+	"CAP_SYS_PTRACE": 19, "CAP_SYS_PACCT": 20, "CAP_SYS_ADMIN": 21, "CAP_SYS_BOOT": 22, "CAP_SYS_NICE": 23,
+	"CAP_SYS_RESOURCE": 24, "CAP_SYS_TIME": 25, "CAP_SYS_TTY_CONFIG": 26, "CAP_MKNOD": 27, "CAP_LEASE": 28,
+	"CAP_AUDIT_WRITE": 29, "CAP_AUDIT_CONTROL": 30, "CAP_SETFCAP": 31, "CAP_MAC_OVERRIDE": 32,
+	"CAP_MAC_ADMIN": 33, "CAP_SYSLOG": 34, "CAP_WAKE_ALARM": 35, "CAP_BLOCK_SUSPEND": 36,
+	"CAP_AUDIT_READ": 37, "CAP_PERFMON": 38, "CAP_BPF": 39, "CAP_CHECKPOINT_RESTORE": 40,
+}
+
+// PropExecStart 创建 ExecStart 属性，argv[0] 为可执行文件路径
+func PropExecStart(argv []string) Property {
+	return Property{
+		Name:  "ExecStart",
+		Value: dbus.MakeVariant([]execCommand{{Path: argv[0], Args: argv}}),
+	}
+}
+
+// StartTransientUnit 创建并启动临时单元
+// 临时单元仅存在于运行时，停止后由 systemd 回收
+func (m *Manager) StartTransientUnit(ctx context.Context, name string, properties []Property) (*Job, error) {
+	var path dbus.ObjectPath
+	call, err := m.call(ctx, objectPath, mngerMethod+".StartTransientUnit", name, "fail", properties, []auxUnit{})
+	if err == nil {
+		err = call.Store(&path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transient unit %s: %w", name, err)
+	}
+	return m.jobs.track(path, name, "start"), nil
+}
+
+// ResetFailedUnit 清除单元的失败状态
+func (m *Manager) ResetFailedUnit(ctx context.Context, serviceName string) error {
+	name := UnitName(serviceName)
+	_, err := m.call(ctx, objectPath, mngerMethod+".ResetFailedUnit", name)
+	if isDBusError(err, errNoSuchUnit) {
+		return &UnitNotFoundError{Unit: name}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reset failed unit %s: %w", name, err)
+	}
+	return nil
+}
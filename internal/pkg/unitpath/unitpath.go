@@ -0,0 +1,94 @@
+package unitpath
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"api-systemd/internal/pkg/logger"
+)
+
+const (
+	// SystemDir 系统管理器的持久单元目录
+	SystemDir = "/etc/systemd/system"
+	// RuntimeDir 系统管理器的运行时单元目录，系统重启后清空
+	RuntimeDir = "/run/systemd/system"
+)
+
+// Resolver 单元文件路径解析，服务层和 HTTP 层通过它定位单元文件、drop-in 和 .control 目录
+type Resolver struct {
+	dir     string // 写入单元文件的目录
+	runtime bool   // 是否为运行时目录
+}
+
+// New 创建路径解析器，dir 为空时使用默认目录：
+// 系统管理器为 /etc/systemd/system，用户管理器为 ~/.config/systemd/user
+func New(dir string, userMode bool) *Resolver {
+	if dir == "" {
+		dir = SystemDir
+		if userMode {
+			dir = userUnitDir()
+		}
+	}
+	dir = filepath.Clean(dir)
+	return &Resolver{dir: dir, runtime: isRuntimeDir(dir)}
+}
+
+// Dir 返回单元目录
+func (r *Resolver) Dir() string {
+	return r.dir
+}
+
+// Runtime 单元目录是否位于 /run（或用户的 XDG_RUNTIME_DIR）下，
+// 此时单元只在本次启动内有效，启用单元也只创建运行时链接
+func (r *Resolver) Runtime() bool {
+	return r.runtime
+}
+
+// Unit 返回单元目录下的文件路径，如 my-app.service、my-app.timer
+func (r *Resolver) Unit(name string) string {
+	return filepath.Join(r.dir, name)
+}
+
+// Service 返回服务的单元文件路径，服务名不带后缀时自动补全 .service
+func (r *Resolver) Service(serviceName string) string {
+	if !strings.HasSuffix(serviceName, ".service") {
+		serviceName += ".service"
+	}
+	return r.Unit(serviceName)
+}
+
+// DropInDir 返回单元的 drop-in 目录
+func (r *Resolver) DropInDir(unitName string) string {
+	return r.Unit(unitName + ".d")
+}
+
+// ControlDir 返回 SetUnitProperties 持久化属性所在的 .control drop-in 目录
+func (r *Resolver) ControlDir(unitName string) string {
+	return filepath.Join(r.dir+".control", unitName+".d")
+}
+
+// isRuntimeDir 判断目录是否位于运行时目录下
+func isRuntimeDir(dir string) bool {
+	roots := []string{"/run"}
+	if xdg := os.Getenv("XDG_RUNTIME_DIR"); xdg != "" {
+		roots = append(roots, xdg)
+	}
+	for _, root := range roots {
+		if dir == root || strings.HasPrefix(dir, filepath.Clean(root)+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// userUnitDir 返回用户管理器的单元目录 ~/.config/systemd/user
+func userUnitDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		logger.Warn(context.Background(), "Failed to resolve user config directory", "error", err)
+		configDir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(configDir, "systemd", "user")
+}
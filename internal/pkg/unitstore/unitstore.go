@@ -0,0 +1,385 @@
+package unitstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLimit 每个文件默认保留的历史版本数量
+const DefaultLimit = 10
+
+// ErrVersionNotFound 历史版本不存在
+var ErrVersionNotFound = errors.New("unit version not found")
+
+// Version 单元文件的一个历史版本
+type Version struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+	Current bool      `json:"current"` // 与单元目录中的文件内容一致
+}
+
+// Store 单元文件写入器
+// 所有写入都先写同目录的临时文件，fsync 后 rename 覆盖目标文件；
+// 单元目录下的文件在工作空间中按相对路径保留最近的 limit 个版本
+type Store struct {
+	mu         sync.Mutex
+	unitDir    string
+	historyDir string
+	limit      int
+	onChange   func(path string)
+}
+
+// New 创建单元文件写入器，limit 不大于 0 时使用 DefaultLimit
+func New(unitDir, historyDir string, limit int) *Store {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Store{
+		unitDir:    unitDir,
+		historyDir: historyDir,
+		limit:      limit,
+	}
+}
+
+// OnChange 注册文件写入或删除成功后的回调，回调在写入器的锁外调用
+func (s *Store) OnChange(fn func(path string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+}
+
+// changed 调用变更回调
+func (s *Store) changed(path string) {
+	s.mu.Lock()
+	fn := s.onChange
+	s.mu.Unlock()
+	if fn != nil {
+		fn(path)
+	}
+}
+
+// WriteFile 原子写入文件，并记录写入前后的内容
+// 写入前的内容未被记录过（如手工修改）时先保存为一个版本
+func (s *Store) WriteFile(path string, content []byte, perm os.FileMode) error {
+	if err := s.writeFile(path, content, perm); err != nil {
+		return err
+	}
+	s.changed(path)
+	return nil
+}
+
+// writeFile 持有锁写入文件并记录版本
+func (s *Store) writeFile(path string, content []byte, perm os.FileMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, tracked := s.name(path)
+	if tracked {
+		if err := s.snapshotCurrent(name, path); err != nil {
+			return err
+		}
+	}
+
+	if err := writeAtomic(path, content, perm); err != nil {
+		return err
+	}
+
+	if tracked {
+		if err := s.record(name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove 删除文件，删除前的内容保留在历史版本中以便恢复
+func (s *Store) Remove(path string) error {
+	if err := s.remove(path); err != nil {
+		return err
+	}
+	s.changed(path)
+	return nil
+}
+
+// remove 持有锁记录当前内容并删除文件
+func (s *Store) remove(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name, tracked := s.name(path); tracked {
+		if err := s.snapshotCurrent(name, path); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// Snapshot 将文件当前内容记录为版本并返回版本号，供失败时通过 Restore 恢复
+// 文件不存在时返回 0，不在单元目录下的文件没有历史版本，返回错误
+func (s *Store) Snapshot(path string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, tracked := s.name(path)
+	if !tracked {
+		return 0, fmt.Errorf("%s is not in the unit directory", path)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err := s.snapshotCurrent(name, path); err != nil {
+		return 0, err
+	}
+	ids, err := s.ids(name)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[len(ids)-1], nil
+}
+
+// Name 返回单元目录下文件的相对路径，用于读取和恢复历史版本
+func (s *Store) Name(path string) (string, bool) {
+	return s.name(path)
+}
+
+// Path 返回单元目录下相对路径对应的文件路径
+func (s *Store) Path(name string) string {
+	return filepath.Join(s.unitDir, name)
+}
+
+// Versions 列出文件的历史版本，按版本号升序排列
+func (s *Store) Versions(name string) ([]Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.ids(name)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _ := os.ReadFile(s.Path(name))
+	currentSum := checksum(current)
+
+	versions := make([]Version, 0, len(ids))
+	for _, id := range ids {
+		path := s.versionFile(name, id)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat version: %w", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read version: %w", err)
+		}
+		sum := checksum(data)
+		versions = append(versions, Version{
+			ID:      id,
+			Time:    info.ModTime(),
+			Size:    info.Size(),
+			SHA256:  sum,
+			Current: current != nil && sum == currentSum,
+		})
+	}
+	return versions, nil
+}
+
+// Read 读取历史版本的内容
+func (s *Store) Read(name string, id int) ([]byte, error) {
+	data, err := os.ReadFile(s.versionFile(name, id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s version %d", ErrVersionNotFound, name, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version: %w", err)
+	}
+	return data, nil
+}
+
+// Diff 比较两个历史版本，to 为 0 时与单元目录中的当前文件比较
+func (s *Store) Diff(name string, from, to int) (string, error) {
+	a, err := s.Read(name, from)
+	if err != nil {
+		return "", err
+	}
+
+	toLabel := fmt.Sprintf("%s@%d", name, to)
+	var b []byte
+	if to == 0 {
+		toLabel = name
+		b, err = os.ReadFile(s.Path(name))
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read unit file: %w", err)
+		}
+	} else if b, err = s.Read(name, to); err != nil {
+		return "", err
+	}
+
+	return UnifiedDiff(string(a), string(b), fmt.Sprintf("%s@%d", name, from), toLabel), nil
+}
+
+// Restore 将历史版本原子写回单元目录，恢复本身也会记录为新版本
+func (s *Store) Restore(name string, id int) error {
+	data, err := s.Read(name, id)
+	if err != nil {
+		return err
+	}
+
+	path := s.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return s.WriteFile(path, data, 0644)
+}
+
+// name 返回文件相对单元目录的路径，不在单元目录下的文件不记录历史
+func (s *Store) name(path string) (string, bool) {
+	rel, err := filepath.Rel(s.unitDir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, true
+}
+
+// snapshotCurrent 当前文件内容与最新版本不同时保存为新版本
+func (s *Store) snapshotCurrent(name, path string) error {
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return s.record(name, current)
+}
+
+// record 内容与最新版本不同时保存为新版本，并清理超出数量的旧版本
+func (s *Store) record(name string, content []byte) error {
+	ids, err := s.ids(name)
+	if err != nil {
+		return err
+	}
+
+	next := 1
+	if len(ids) > 0 {
+		last := ids[len(ids)-1]
+		if latest, err := os.ReadFile(s.versionFile(name, last)); err == nil && bytes.Equal(latest, content) {
+			return nil
+		}
+		next = last + 1
+	}
+
+	if err := os.MkdirAll(s.versionDir(name), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := writeAtomic(s.versionFile(name, next), content, 0644); err != nil {
+		return fmt.Errorf("failed to record version: %w", err)
+	}
+
+	ids = append(ids, next)
+	for len(ids) > s.limit {
+		if err := os.Remove(s.versionFile(name, ids[0])); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to prune version: %w", err)
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// ids 返回文件已有的版本号，按升序排列
+func (s *Store) ids(name string) ([]int, error) {
+	entries, err := os.ReadDir(s.versionDir(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var ids []int
+	for _, entry := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".unit"))
+		if err != nil || entry.IsDir() || !strings.HasSuffix(entry.Name(), ".unit") {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// versionDir 返回文件历史版本所在目录
+func (s *Store) versionDir(name string) string {
+	return filepath.Join(s.historyDir, name)
+}
+
+// versionFile 返回历史版本文件路径
+func (s *Store) versionFile(name string, id int) string {
+	return filepath.Join(s.versionDir(name), fmt.Sprintf("%06d.unit", id))
+}
+
+// writeAtomic 写入同目录的临时文件，fsync 后 rename 覆盖目标文件并 fsync 目录
+func writeAtomic(path string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // rename 成功后删除不存在的文件，忽略错误
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to chmod temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return syncDir(dir)
+}
+
+// syncDir fsync 目录，确保 rename 和删除落盘
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
+
+// checksum 计算内容的 SHA-256
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,267 @@
+package unitstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestStore 创建使用临时目录的写入器
+func newTestStore(t *testing.T, limit int) *Store {
+	t.Helper()
+	dir := t.TempDir()
+	unitDir := filepath.Join(dir, "units")
+	if err := os.MkdirAll(unitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return New(unitDir, filepath.Join(dir, "history"), limit)
+}
+
+// versionIDs 返回文件的历史版本号
+func versionIDs(t *testing.T, s *Store, name string) []int {
+	t.Helper()
+	versions, err := s.Versions(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, v := range versions {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+func TestWriteFileRecordsVersions(t *testing.T) {
+	s := newTestStore(t, 0)
+	path := s.Path("app.service")
+
+	for _, content := range []string{"v1", "v2", "v2", "v3"} {
+		if err := s.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 内容未变化的写入不产生新版本
+	if ids := versionIDs(t, s, "app.service"); !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+		t.Fatalf("versions = %v, want [1 2 3]", ids)
+	}
+	versions, _ := s.Versions("app.service")
+	for _, v := range versions {
+		if v.Current != (v.ID == 3) {
+			t.Errorf("version %d current = %v", v.ID, v.Current)
+		}
+	}
+}
+
+func TestWriteFileRecordsManualEdits(t *testing.T) {
+	s := newTestStore(t, 0)
+	path := s.Path("app.service")
+
+	if err := s.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("manual"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteFile(path, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.Read("app.service", 2)
+	if err != nil || string(data) != "manual" {
+		t.Fatalf("version 2 = %q, %v, want manual edit", data, err)
+	}
+}
+
+func TestVersionRotation(t *testing.T) {
+	tests := []struct {
+		limit  int
+		writes int
+		want   []int
+	}{
+		{limit: 3, writes: 2, want: []int{1, 2}},
+		{limit: 3, writes: 3, want: []int{1, 2, 3}},
+		{limit: 3, writes: 5, want: []int{3, 4, 5}},
+		{limit: 1, writes: 4, want: []int{4}},
+	}
+	for _, tt := range tests {
+		s := newTestStore(t, tt.limit)
+		path := s.Path("app.service")
+		for i := 1; i <= tt.writes; i++ {
+			if err := s.WriteFile(path, []byte(strings.Repeat("x", i)), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if ids := versionIDs(t, s, "app.service"); !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("limit %d, %d writes: versions = %v, want %v", tt.limit, tt.writes, ids, tt.want)
+		}
+	}
+}
+
+func TestUntrackedPath(t *testing.T) {
+	s := newTestStore(t, 0)
+	outside := filepath.Join(t.TempDir(), "other.conf")
+
+	if err := s.WriteFile(outside, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, tracked := s.Name(outside); tracked {
+		t.Error("file outside the unit directory is tracked")
+	}
+	if _, err := s.Snapshot(outside); err == nil {
+		t.Error("Snapshot of untracked file succeeded, want error")
+	}
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	s := newTestStore(t, 0)
+	path := s.Path("app.service.d/override.conf")
+
+	// 文件不存在时版本号为 0
+	if id, err := s.Snapshot(path); err != nil || id != 0 {
+		t.Fatalf("Snapshot(missing) = %d, %v, want 0", id, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.Snapshot(path)
+	if err != nil || id != 1 {
+		t.Fatalf("Snapshot = %d, %v, want 1", id, err)
+	}
+	// 内容未变化时返回已有版本
+	if again, _ := s.Snapshot(path); again != id {
+		t.Fatalf("second Snapshot = %d, want %d", again, id)
+	}
+
+	if err := s.WriteFile(path, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Restore("app.service.d/override.conf", id); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "original" {
+		t.Fatalf("restored content = %q, want original", data)
+	}
+	if ids := versionIDs(t, s, "app.service.d/override.conf"); !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+		t.Fatalf("versions = %v, want restore recorded as version 3", ids)
+	}
+
+	if err := s.Restore("app.service.d/override.conf", 42); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("Restore(missing) = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestRemoveKeepsHistory(t *testing.T) {
+	s := newTestStore(t, 0)
+	path := s.Path("app.service")
+
+	if err := os.WriteFile(path, []byte("unrecorded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file still exists: %v", err)
+	}
+	data, err := s.Read("app.service", 1)
+	if err != nil || string(data) != "unrecorded" {
+		t.Fatalf("version 1 = %q, %v", data, err)
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	s := newTestStore(t, 0)
+	path := s.Path("app.service")
+
+	var changed []string
+	s.OnChange(func(p string) {
+		// 回调在锁外调用，可以再次访问写入器
+		if _, err := s.Versions("app.service"); err != nil {
+			t.Error(err)
+		}
+		changed = append(changed, p)
+	})
+
+	if err := s.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	// 失败的操作不通知
+	if err := s.Remove(path); err == nil {
+		t.Fatal("removing a missing file succeeded")
+	}
+
+	if !reflect.DeepEqual(changed, []string{path, path}) {
+		t.Fatalf("changed = %v", changed)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	s := newTestStore(t, 0)
+	path := s.Path("app.service")
+	for _, content := range []string{"a\nb\nc\n", "a\nB\nc\n"} {
+		if err := s.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.Diff("app.service", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- app.service@1\n+++ app.service@2\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"
+	if got != want {
+		t.Fatalf("diff =\n%s\nwant\n%s", got, want)
+	}
+
+	// 与当前文件比较
+	if got, err := s.Diff("app.service", 2, 0); err != nil || got != "" {
+		t.Fatalf("diff against current = %q, %v, want empty", got, err)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "identical", a: "x\n", b: "x\n", want: ""},
+		{
+			name: "added line",
+			a:    "a\n",
+			b:    "a\nb\n",
+			want: "--- old\n+++ new\n@@ -1,1 +1,2 @@\n a\n+b\n",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "a\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff(tt.a, tt.b, "old", "new"); got != tt.want {
+				t.Fatalf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,14 @@
 package validator
 
 import (
+	"api-systemd/internal/pkg/hooks"
+	"api-systemd/internal/pkg/systemd"
 	"errors"
+	"fmt"
 	"net/url"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +18,31 @@ var (
 	ErrEmptyPath          = errors.New("path cannot be empty")
 	ErrInvalidURL         = errors.New("invalid package URL")
 	ErrEmptyStartCommand  = errors.New("start command cannot be empty")
+	ErrEmptyTimerConfig   = errors.New("timer config cannot be empty")
+	ErrNoTimerTrigger     = errors.New("timer requires at least one of on_calendar, on_boot_sec or on_unit_active_sec")
+	ErrInvalidTimerValue  = errors.New("timer value contains invalid characters")
+	ErrInvalidDropInName  = errors.New("drop-in name must contain only letters, digits, '.', '_' and '-' and end with .conf")
+	ErrInvalidConfig      = errors.New("invalid service config")
+)
+
+var (
+	serviceTypes   = []string{"simple", "exec", "forking", "oneshot", "notify", "idle"}
+	restartValues  = []string{"no", "always", "on-success", "on-failure", "on-abnormal", "on-abort", "on-watchdog"}
+	killModes      = []string{"control-group", "mixed", "process", "none"}
+	outputTargets  = []string{"inherit", "null", "tty", "journal", "kmsg", "journal+console", "kmsg+console", "socket"}
+	outputFileKind = []string{"file:", "append:", "truncate:"}
+	profiles       = []string{hooks.HardeningNone, hooks.HardeningStandard, hooks.HardeningStrict}
+	protectSystems = []string{"no", "yes", "full", "strict"}
+	protectHomes   = []string{"no", "yes", "read-only", "tmpfs"}
+
+	// timespanPattern systemd 时间格式，如 "90"、"1min 30s"、"500ms"
+	timespanPattern = regexp.MustCompile(`^(infinity|([0-9]+(\.[0-9]+)?\s*(us|usec|ms|msec|s|sec|seconds?|m|min|minutes?|h|hr|hours?|d|days?|w|weeks?)?\s*)+)$`)
+	umaskPattern    = regexp.MustCompile(`^[0-7]{3,4}$`)
+	targetPattern   = regexp.MustCompile(`^[a-zA-Z0-9_.@:-]+\.target$`)
+	familyPattern   = regexp.MustCompile(`^(none|~?AF_[A-Z0-9]+)$`)
+	capPattern      = regexp.MustCompile(`^~?CAP_[A-Z_]+$`)
+	syscallPattern  = regexp.MustCompile(`^~?@?[a-z0-9_-]+(:[A-Z0-9]+)?$`)
+	fdNamePattern   = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,255}$`)
 )
 
 // ValidateServiceName 验证服务名称
@@ -30,6 +60,25 @@ func ValidateServiceName(serviceName string) error {
 	return nil
 }
 
+// ValidateInstanceName 验证服务名或模板实例名（name@instance）
+func ValidateInstanceName(name string) error {
+	base, instance, ok := strings.Cut(name, "@")
+	if err := ValidateServiceName(base); err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	// 实例名不能为空，且不允许路径分隔符
+	matched, _ := regexp.MatchString(`^[a-zA-Z0-9_.-]+$`, instance)
+	if !matched {
+		return ErrInvalidServiceName
+	}
+
+	return nil
+}
+
 // ValidatePath 验证路径
 func ValidatePath(path string) error {
 	if strings.TrimSpace(path) == "" {
@@ -91,3 +140,267 @@ func ValidateDeployParams(service, path, packageURL, start string) error {
 
 	return nil
 }
+
+// ValidateTimerConfig 验证定时器配置
+func ValidateTimerConfig(timer *hooks.TimerConfig) error {
+	if timer == nil {
+		return ErrEmptyTimerConfig
+	}
+
+	if len(timer.OnCalendar) == 0 && timer.OnBootSec == "" && timer.OnUnitActiveSec == "" {
+		return ErrNoTimerTrigger
+	}
+
+	// 定时器取值会直接写入单元文件，不允许换行等控制字符
+	values := append([]string{timer.OnBootSec, timer.OnUnitActiveSec, timer.RandomizedDelaySec}, timer.OnCalendar...)
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return ErrInvalidTimerValue
+		}
+	}
+	for _, calendar := range timer.OnCalendar {
+		if strings.TrimSpace(calendar) == "" {
+			return ErrInvalidTimerValue
+		}
+	}
+
+	return nil
+}
+
+// ValidateDropInName 验证 drop-in 文件名
+func ValidateDropInName(name string) error {
+	// 不允许路径分隔符和隐藏文件，避免写出 drop-in 目录
+	matched, _ := regexp.MatchString(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*\.conf$`, name)
+	if !matched {
+		return ErrInvalidDropInName
+	}
+
+	return nil
+}
+
+// ValidateServiceConfig 验证服务配置中会渲染到单元文件的 [Service] 和 [Install] 选项
+func ValidateServiceConfig(config *hooks.ServiceConfig) error {
+	if config == nil {
+		return nil
+	}
+
+	values := []string{
+		config.ExecReload, config.ExecStop, config.Type, config.PIDFile, config.UMask,
+		config.StartLimitIntervalSec, config.TimeoutStartSec, config.TimeoutStopSec,
+		config.KillMode, config.KillSignal, config.StandardOutput, config.StandardError,
+		config.LimitNOFILE, config.InstallTarget,
+	}
+	values = append(values, config.EnvironmentFile...)
+	values = append(values, config.SuccessExitStatus...)
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("%w: value %q contains control characters", ErrInvalidConfig, value)
+		}
+	}
+
+	if config.Type != "" && !contains(serviceTypes, config.Type) {
+		return fmt.Errorf("%w: type must be one of %s", ErrInvalidConfig, strings.Join(serviceTypes, ", "))
+	}
+	if config.RestartPolicy != "" && !contains(restartValues, config.RestartPolicy) {
+		return fmt.Errorf("%w: restart_policy must be one of %s", ErrInvalidConfig, strings.Join(restartValues, ", "))
+	}
+	// systemd 拒绝加载 Restart=always 或 on-success 的 oneshot 服务
+	if config.Type == "oneshot" && (config.RestartPolicy == "always" || config.RestartPolicy == "on-success") {
+		return fmt.Errorf("%w: restart_policy %s is not allowed for oneshot services", ErrInvalidConfig, config.RestartPolicy)
+	}
+	if config.PIDFile != "" && !filepath.IsAbs(config.PIDFile) {
+		return fmt.Errorf("%w: pid_file must be an absolute path", ErrInvalidConfig)
+	}
+
+	for name, value := range map[string]string{
+		"start_limit_interval_sec": config.StartLimitIntervalSec,
+		"timeout_start_sec":        config.TimeoutStartSec,
+		"timeout_stop_sec":         config.TimeoutStopSec,
+	} {
+		if value != "" && !timespanPattern.MatchString(value) {
+			return fmt.Errorf("%w: %s %q is not a valid time span", ErrInvalidConfig, name, value)
+		}
+	}
+
+	if config.KillMode != "" && !contains(killModes, config.KillMode) {
+		return fmt.Errorf("%w: kill_mode must be one of %s", ErrInvalidConfig, strings.Join(killModes, ", "))
+	}
+	if config.KillSignal != "" {
+		if _, err := systemd.ParseSignal(config.KillSignal); err != nil {
+			return fmt.Errorf("%w: kill_signal: %v", ErrInvalidConfig, err)
+		}
+	}
+	for _, status := range config.SuccessExitStatus {
+		if err := validateExitStatus(status); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range map[string]string{
+		"standard_output": config.StandardOutput,
+		"standard_error":  config.StandardError,
+	} {
+		if value != "" && !validOutput(value) {
+			return fmt.Errorf("%w: %s %q must be one of %s, file:/path, append:/path, truncate:/path or fd:name",
+				ErrInvalidConfig, name, value, strings.Join(outputTargets, ", "))
+		}
+	}
+
+	if config.LimitNOFILE != "" {
+		if err := validateLimit(config.LimitNOFILE); err != nil {
+			return err
+		}
+	}
+	if config.Nice != nil && (*config.Nice < -20 || *config.Nice > 19) {
+		return fmt.Errorf("%w: nice must be between -20 and 19", ErrInvalidConfig)
+	}
+	if config.UMask != "" && !umaskPattern.MatchString(config.UMask) {
+		return fmt.Errorf("%w: umask must be an octal value such as 0022", ErrInvalidConfig)
+	}
+	for _, file := range config.EnvironmentFile {
+		if !filepath.IsAbs(strings.TrimPrefix(file, "-")) {
+			return fmt.Errorf("%w: environment_file %q must be an absolute path", ErrInvalidConfig, file)
+		}
+	}
+	if config.InstallTarget != "" && !targetPattern.MatchString(config.InstallTarget) {
+		return fmt.Errorf("%w: install_target %q must be a .target unit", ErrInvalidConfig, config.InstallTarget)
+	}
+
+	if err := validateHardening(config.Hardening); err != nil {
+		return err
+	}
+	return validateSocket(config.Socket)
+}
+
+// validateSocket 验证套接字激活配置
+func validateSocket(socket *hooks.SocketConfig) error {
+	if socket == nil {
+		return nil
+	}
+
+	if len(socket.ListenStream) == 0 && len(socket.ListenDatagram) == 0 {
+		return fmt.Errorf("%w: socket requires at least one of listen_stream or listen_datagram", ErrInvalidConfig)
+	}
+	for _, listen := range append(append([]string{}, socket.ListenStream...), socket.ListenDatagram...) {
+		if listen == "" || strings.ContainsAny(listen, "\r\n\x00 \t") {
+			return fmt.Errorf("%w: invalid socket listen address %q", ErrInvalidConfig, listen)
+		}
+	}
+	// Accept=yes 时每个连接单独启动实例，只支持流式套接字
+	if socket.Accept && len(socket.ListenDatagram) > 0 {
+		return fmt.Errorf("%w: socket accept is not supported for listen_datagram", ErrInvalidConfig)
+	}
+	if socket.SocketMode != "" && !umaskPattern.MatchString(socket.SocketMode) {
+		return fmt.Errorf("%w: socket_mode must be an octal value such as 0660", ErrInvalidConfig)
+	}
+	if socket.FileDescriptorName != "" && !fdNamePattern.MatchString(socket.FileDescriptorName) {
+		return fmt.Errorf("%w: file_descriptor_name %q must contain only letters, digits, '.', '_' and '-'", ErrInvalidConfig, socket.FileDescriptorName)
+	}
+
+	return nil
+}
+
+// validateHardening 验证沙箱加固配置
+func validateHardening(h *hooks.HardeningConfig) error {
+	if h == nil {
+		return nil
+	}
+
+	if h.Profile != "" && !contains(profiles, h.Profile) {
+		return fmt.Errorf("%w: hardening profile must be one of %s", ErrInvalidConfig, strings.Join(profiles, ", "))
+	}
+	if h.ProtectSystem != "" && !contains(protectSystems, h.ProtectSystem) {
+		return fmt.Errorf("%w: protect_system must be one of %s", ErrInvalidConfig, strings.Join(protectSystems, ", "))
+	}
+	if h.ProtectHome != "" && !contains(protectHomes, h.ProtectHome) {
+		return fmt.Errorf("%w: protect_home must be one of %s", ErrInvalidConfig, strings.Join(protectHomes, ", "))
+	}
+	for _, family := range h.RestrictAddressFamilies {
+		if !familyPattern.MatchString(family) {
+			return fmt.Errorf("%w: invalid address family %q", ErrInvalidConfig, family)
+		}
+	}
+	for _, capability := range h.CapabilityBoundingSet {
+		if !capPattern.MatchString(capability) {
+			return fmt.Errorf("%w: invalid capability %q", ErrInvalidConfig, capability)
+		}
+	}
+	for _, syscall := range h.SystemCallFilter {
+		if !syscallPattern.MatchString(syscall) {
+			return fmt.Errorf("%w: invalid system call filter %q", ErrInvalidConfig, syscall)
+		}
+	}
+	for _, path := range h.ReadWritePaths {
+		if strings.ContainsAny(path, "\r\n\x00 ") || !filepath.IsAbs(strings.TrimPrefix(path, "-")) {
+			return fmt.Errorf("%w: read_write_paths %q must be an absolute path", ErrInvalidConfig, path)
+		}
+	}
+
+	return nil
+}
+
+// validateExitStatus 验证退出状态码（0-255）或信号名
+func validateExitStatus(status string) error {
+	if n, err := strconv.Atoi(status); err == nil {
+		if n < 0 || n > 255 {
+			return fmt.Errorf("%w: success_exit_status %d must be between 0 and 255", ErrInvalidConfig, n)
+		}
+		return nil
+	}
+	if _, err := systemd.ParseSignal(status); err != nil {
+		return fmt.Errorf("%w: success_exit_status: %v", ErrInvalidConfig, err)
+	}
+	return nil
+}
+
+// validOutput 判断是否为有效的 StandardOutput/StandardError 取值
+func validOutput(value string) bool {
+	if contains(outputTargets, value) {
+		return true
+	}
+	for _, prefix := range outputFileKind {
+		if path, ok := strings.CutPrefix(value, prefix); ok {
+			return filepath.IsAbs(path)
+		}
+	}
+	if name, ok := strings.CutPrefix(value, "fd:"); ok {
+		return name != ""
+	}
+	return false
+}
+
+// validateLimit 验证资源限制取值，如 "65536"、"1024:65536"、"infinity"
+func validateLimit(value string) error {
+	soft, hard, hasHard := strings.Cut(value, ":")
+	if !hasHard {
+		hard = soft
+	}
+	parse := func(s string) (uint64, error) {
+		if s == "infinity" {
+			return ^uint64(0), nil
+		}
+		return strconv.ParseUint(s, 10, 64)
+	}
+	softN, err := parse(soft)
+	if err != nil {
+		return fmt.Errorf("%w: limit_nofile %q must be a number, soft:hard or infinity", ErrInvalidConfig, value)
+	}
+	hardN, err := parse(hard)
+	if err != nil {
+		return fmt.Errorf("%w: limit_nofile %q must be a number, soft:hard or infinity", ErrInvalidConfig, value)
+	}
+	if softN > hardN {
+		return fmt.Errorf("%w: limit_nofile soft limit exceeds hard limit", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// contains 判断字符串列表是否包含 value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
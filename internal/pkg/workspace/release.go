@@ -0,0 +1,286 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultReleaseLimit 每个服务默认保留的版本数量
+const DefaultReleaseLimit = 5
+
+// releaseIDLayout 版本号格式，按部署时间（UTC）生成，字典序即时间顺序
+const releaseIDLayout = "20060102-150405.000"
+
+// releaseRootFile 记录版本根目录的文件，位于版本目录下，内容为产物的顶层目录名
+const releaseRootFile = ".release-root"
+
+var (
+	// ErrReleaseNotFound 服务不存在指定的版本
+	ErrReleaseNotFound = errors.New("release not found")
+	// ErrNoPreviousRelease 当前版本之前没有可回滚的版本
+	ErrNoPreviousRelease = errors.New("no previous release to roll back to")
+)
+
+// Release 服务的一个已解压版本
+type Release struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Root      string    `json:"root"` // 版本根目录，current 链接指向该目录
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"` // current 符号链接是否指向该版本
+}
+
+// StageRelease 在版本目录下创建暂存目录，产物解压到暂存目录，成功后通过 CommitRelease 提交
+// 调用方负责在结束后删除暂存目录
+func (m *Manager) StageRelease(serviceName string) (string, error) {
+	releasesDir := m.GetReleasesDir(serviceName)
+	if err := os.MkdirAll(releasesDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create releases directory %s: %w", releasesDir, err)
+	}
+	staging, err := os.MkdirTemp(releasesDir, ".staging-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if err := os.Chmod(staging, 0755); err != nil {
+		os.RemoveAll(staging)
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return staging, nil
+}
+
+// CommitRelease 将整个暂存目录重命名为新版本目录并返回该版本，产物的其他顶层条目随版本保留
+// folder 为产物的第一个顶层目录，与直接解压到服务目录时一样作为版本根目录；不是目录时以版本目录作为根目录
+func (m *Manager) CommitRelease(serviceName, stagingDir, folder string) (*Release, error) {
+	if folder != "" {
+		if strings.ContainsRune(folder, filepath.Separator) || folder == "." || folder == ".." {
+			return nil, fmt.Errorf("invalid release root %q", folder)
+		}
+		if info, err := os.Stat(filepath.Join(stagingDir, folder)); err != nil || !info.IsDir() {
+			folder = ""
+		}
+	}
+	if folder != "" {
+		if err := os.WriteFile(filepath.Join(stagingDir, releaseRootFile), []byte(folder+"\n"), 0644); err != nil {
+			return nil, fmt.Errorf("failed to record release root: %w", err)
+		}
+	}
+
+	id, createdAt := m.newReleaseID(serviceName)
+	dir := filepath.Join(m.GetReleasesDir(serviceName), id)
+	if err := os.Rename(stagingDir, dir); err != nil {
+		return nil, fmt.Errorf("failed to commit release %s: %w", id, err)
+	}
+	return &Release{ID: id, Path: dir, Root: filepath.Join(dir, folder), CreatedAt: createdAt}, nil
+}
+
+// releaseRoot 返回版本根目录相对版本目录的路径，没有记录时为版本目录本身
+func (m *Manager) releaseRoot(serviceName, id string) string {
+	data, err := os.ReadFile(filepath.Join(m.GetReleasesDir(serviceName), id, releaseRootFile))
+	if err != nil {
+		return ""
+	}
+	folder := strings.TrimSpace(string(data))
+	if strings.ContainsRune(folder, filepath.Separator) || folder == "." || folder == ".." {
+		return ""
+	}
+	return folder
+}
+
+// ReleaseOf 返回路径所在的版本号，路径不在服务的版本目录下时返回空字符串
+func (m *Manager) ReleaseOf(serviceName, path string) string {
+	dir := m.GetReleasesDir(serviceName)
+	// 进程的工作目录是解析过符号链接的路径
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return strings.SplitN(rel, string(filepath.Separator), 2)[0]
+}
+
+// newReleaseID 按当前时间生成不重复的版本号
+func (m *Manager) newReleaseID(serviceName string) (string, time.Time) {
+	t := time.Now().UTC()
+	for {
+		id := t.Format(releaseIDLayout)
+		if _, err := os.Lstat(filepath.Join(m.GetReleasesDir(serviceName), id)); os.IsNotExist(err) {
+			return id, t.Truncate(time.Millisecond)
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// ActivateRelease 将 current 符号链接原子切换到指定版本的根目录
+// 先在同目录创建临时链接，再通过 rename 覆盖 current，切换过程中 current 始终有效
+func (m *Manager) ActivateRelease(serviceName, id string) error {
+	if _, err := m.GetRelease(serviceName, id); err != nil {
+		return err
+	}
+
+	current := m.GetCurrentDir(serviceName)
+	tmp := fmt.Sprintf("%s.%d.tmp", current, time.Now().UnixNano())
+	if err := os.Symlink(filepath.Join("releases", id, m.releaseRoot(serviceName, id)), tmp); err != nil {
+		return fmt.Errorf("failed to create release link: %w", err)
+	}
+	if err := os.Rename(tmp, current); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to switch current release: %w", err)
+	}
+
+	// 持久化目录项，避免断电后链接回到旧版本
+	if dir, err := os.Open(filepath.Dir(current)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// DeactivateRelease 删除 current 符号链接，用于首次部署失败后撤销激活
+func (m *Manager) DeactivateRelease(serviceName string) error {
+	if err := os.Remove(m.GetCurrentDir(serviceName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove current release link: %w", err)
+	}
+	return nil
+}
+
+// RemoveRelease 删除指定版本，不允许删除当前版本
+func (m *Manager) RemoveRelease(serviceName, id string) error {
+	release, err := m.GetRelease(serviceName, id)
+	if err != nil {
+		return err
+	}
+	if release.Current {
+		return fmt.Errorf("refusing to remove current release %s", id)
+	}
+	if err := os.RemoveAll(release.Path); err != nil {
+		return fmt.Errorf("failed to remove release %s: %w", id, err)
+	}
+	return nil
+}
+
+// CurrentRelease 返回 current 符号链接指向的版本号，尚未部署版本时返回空字符串
+func (m *Manager) CurrentRelease(serviceName string) (string, error) {
+	target, err := os.Readlink(m.GetCurrentDir(serviceName))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read current release: %w", err)
+	}
+	// 链接指向 releases/<id> 或 releases/<id>/<root>
+	parts := strings.Split(filepath.Clean(target), string(filepath.Separator))
+	if len(parts) < 2 || parts[0] != "releases" {
+		return "", fmt.Errorf("unexpected current release link %q", target)
+	}
+	return parts[1], nil
+}
+
+// ListReleases 列出服务的版本，按部署时间从旧到新排序
+func (m *Manager) ListReleases(serviceName string) ([]Release, error) {
+	entries, err := os.ReadDir(m.GetReleasesDir(serviceName))
+	if os.IsNotExist(err) {
+		return []Release{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read releases directory: %w", err)
+	}
+
+	current, err := m.CurrentRelease(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := []Release{}
+	for _, entry := range entries {
+		// 跳过暂存目录和其他不是版本的文件
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		createdAt, err := time.Parse(releaseIDLayout, entry.Name())
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(m.GetReleasesDir(serviceName), entry.Name())
+		releases = append(releases, Release{
+			ID:        entry.Name(),
+			Path:      path,
+			Root:      filepath.Join(path, m.releaseRoot(serviceName, entry.Name())),
+			CreatedAt: createdAt,
+			Current:   entry.Name() == current,
+		})
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].ID < releases[j].ID
+	})
+	return releases, nil
+}
+
+// GetRelease 获取指定版本，不存在时返回 ErrReleaseNotFound
+func (m *Manager) GetRelease(serviceName, id string) (*Release, error) {
+	releases, err := m.ListReleases(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	for i := range releases {
+		if releases[i].ID == id {
+			return &releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrReleaseNotFound, id)
+}
+
+// PreviousRelease 返回当前版本之前最近的一个版本，没有时返回 ErrNoPreviousRelease
+func (m *Manager) PreviousRelease(serviceName string) (*Release, error) {
+	releases, err := m.ListReleases(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(releases) - 1; i > 0; i-- {
+		if releases[i].Current {
+			return &releases[i-1], nil
+		}
+	}
+	return nil, ErrNoPreviousRelease
+}
+
+// PruneReleases 只保留最近的 keep 个版本，当前版本和 inUse 中仍被进程使用的版本不会被删除，返回删除的版本号
+// keep 不大于 0 时使用 DefaultReleaseLimit
+func (m *Manager) PruneReleases(serviceName string, keep int, inUse ...string) ([]string, error) {
+	if keep <= 0 {
+		keep = DefaultReleaseLimit
+	}
+	releases, err := m.ListReleases(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i := 0; i < len(releases)-keep; i++ {
+		if releases[i].Current || contains(inUse, releases[i].ID) {
+			continue
+		}
+		if err := os.RemoveAll(releases[i].Path); err != nil {
+			return removed, fmt.Errorf("failed to remove release %s: %w", releases[i].ID, err)
+		}
+		removed = append(removed, releases[i].ID)
+	}
+	return removed, nil
+}
+
+// contains 判断切片中是否包含指定字符串
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestManager 创建使用临时目录的工作空间
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager(t.TempDir())
+	if err := m.InitWorkspace(); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// stageRelease 创建暂存目录并写入文件，files 的键为相对暂存目录的路径
+func stageRelease(t *testing.T, m *Manager, files map[string]string) string {
+	t.Helper()
+	staging, err := m.StageRelease("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(staging, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return staging
+}
+
+// commitReleases 提交 n 个只含 bin/app 的版本，返回版本号
+func commitReleases(t *testing.T, m *Manager, n int) []string {
+	t.Helper()
+	var ids []string
+	for i := 0; i < n; i++ {
+		staging := stageRelease(t, m, map[string]string{"bin/app": "x"})
+		release, err := m.CommitRelease("app", staging, "bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, release.ID)
+	}
+	return ids
+}
+
+func TestCommitReleaseRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		folder   string
+		wantRoot string // 相对版本目录
+		wantErr  bool
+	}{
+		{
+			name:     "first folder is the root, siblings kept",
+			files:    map[string]string{"app-1.0/bin/app": "x", "README": "readme", "extra/data": "d"},
+			folder:   "app-1.0",
+			wantRoot: "app-1.0",
+		},
+		{
+			name:     "first entry is a file",
+			files:    map[string]string{"app": "binary"},
+			folder:   "app",
+			wantRoot: "",
+		},
+		{
+			name:     "no folder",
+			files:    map[string]string{"app": "binary"},
+			folder:   "",
+			wantRoot: "",
+		},
+		{name: "nested folder", files: map[string]string{"a/b/c": "x"}, folder: "a/b", wantErr: true},
+		{name: "parent folder", files: map[string]string{"a": "x"}, folder: "..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			staging := stageRelease(t, m, tt.files)
+
+			release, err := m.CommitRelease("app", staging, tt.folder)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("CommitRelease succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := filepath.Join(release.Path, tt.wantRoot); release.Root != want {
+				t.Fatalf("root = %s, want %s", release.Root, want)
+			}
+			if _, err := os.Stat(staging); !os.IsNotExist(err) {
+				t.Fatalf("staging directory still exists: %v", err)
+			}
+			for name := range tt.files {
+				if _, err := os.Stat(filepath.Join(release.Path, name)); err != nil {
+					t.Errorf("%s not kept in release: %v", name, err)
+				}
+			}
+
+			// 重新列出版本时根目录一致
+			got, err := m.GetRelease("app", release.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Root != release.Root {
+				t.Fatalf("listed root = %s, want %s", got.Root, release.Root)
+			}
+		})
+	}
+}
+
+func TestActivateRelease(t *testing.T) {
+	m := newTestManager(t)
+
+	if id, err := m.CurrentRelease("app"); err != nil || id != "" {
+		t.Fatalf("CurrentRelease before deploy = %q, %v", id, err)
+	}
+
+	staging := stageRelease(t, m, map[string]string{"app-1.0/bin/app": "v1"})
+	first, err := m.CommitRelease("app", staging, "app-1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging = stageRelease(t, m, map[string]string{"app": "v2"})
+	second, err := m.CommitRelease("app", staging, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		release *Release
+		link    string
+		file    string
+	}{
+		{first, filepath.Join("releases", first.ID, "app-1.0"), "bin/app"},
+		{second, filepath.Join("releases", second.ID), "app"},
+	}
+	for _, tt := range tests {
+		if err := m.ActivateRelease("app", tt.release.ID); err != nil {
+			t.Fatal(err)
+		}
+		link, err := os.Readlink(m.GetCurrentDir("app"))
+		if err != nil || link != tt.link {
+			t.Fatalf("current -> %q, %v, want %q", link, err, tt.link)
+		}
+		if id, err := m.CurrentRelease("app"); err != nil || id != tt.release.ID {
+			t.Fatalf("CurrentRelease = %q, %v, want %q", id, err, tt.release.ID)
+		}
+		if _, err := os.Stat(filepath.Join(m.GetCurrentDir("app"), tt.file)); err != nil {
+			t.Fatalf("%s not reachable through current: %v", tt.file, err)
+		}
+	}
+
+	previous, err := m.PreviousRelease("app")
+	if err != nil || previous.ID != first.ID {
+		t.Fatalf("PreviousRelease = %+v, %v, want %s", previous, err, first.ID)
+	}
+
+	if err := m.ActivateRelease("app", "20000101-000000.000"); !errors.Is(err, ErrReleaseNotFound) {
+		t.Fatalf("ActivateRelease(missing) = %v, want ErrReleaseNotFound", err)
+	}
+
+	if err := m.DeactivateRelease("app"); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := m.CurrentRelease("app"); err != nil || id != "" {
+		t.Fatalf("CurrentRelease after deactivate = %q, %v", id, err)
+	}
+}
+
+func TestPruneReleases(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int // 当前版本的下标
+		inUse   []int
+		keep    int
+		removed []int
+	}{
+		{name: "keep latest", current: 4, keep: 2, removed: []int{0, 1, 2}},
+		{name: "keep current after rollback", current: 1, keep: 2, removed: []int{0, 2}},
+		{name: "keep releases in use", current: 4, inUse: []int{0, 2}, keep: 2, removed: []int{1}},
+		{name: "nothing to prune", current: 4, keep: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			ids := commitReleases(t, m, 5)
+			if err := m.ActivateRelease("app", ids[tt.current]); err != nil {
+				t.Fatal(err)
+			}
+			var inUse []string
+			for _, i := range tt.inUse {
+				inUse = append(inUse, ids[i])
+			}
+			var want []string
+			for _, i := range tt.removed {
+				want = append(want, ids[i])
+			}
+
+			removed, err := m.PruneReleases("app", tt.keep, inUse...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(removed, want) {
+				t.Fatalf("removed = %v, want %v", removed, want)
+			}
+			for _, id := range want {
+				if _, err := m.GetRelease("app", id); !errors.Is(err, ErrReleaseNotFound) {
+					t.Errorf("release %s still listed: %v", id, err)
+				}
+			}
+			if _, err := m.GetRelease("app", ids[tt.current]); err != nil {
+				t.Errorf("current release removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestRemoveRelease(t *testing.T) {
+	m := newTestManager(t)
+	ids := commitReleases(t, m, 2)
+	if err := m.ActivateRelease("app", ids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.RemoveRelease("app", ids[1]); err == nil {
+		t.Fatal("removing the current release succeeded")
+	}
+	if err := m.RemoveRelease("app", ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetRelease("app", ids[0]); !errors.Is(err, ErrReleaseNotFound) {
+		t.Fatalf("GetRelease(removed) = %v, want ErrReleaseNotFound", err)
+	}
+}
+
+func TestReleaseOf(t *testing.T) {
+	m := newTestManager(t)
+	ids := commitReleases(t, m, 1)
+	releasesDir, err := filepath.EvalSymlinks(m.GetReleasesDir("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(releasesDir, ids[0], "bin"), ids[0]},
+		{filepath.Join(releasesDir, ids[0]), ids[0]},
+		{releasesDir, ""},
+		{m.GetLogDir("app"), ""},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		if got := m.ReleaseOf("app", tt.path); got != tt.want {
+			t.Errorf("ReleaseOf(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,11 +1,20 @@
 package workspace
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+
+	"api-systemd/internal/pkg/hooks"
 )
 
+// ErrConfigNotFound 服务未记录部署配置
+var ErrConfigNotFound = errors.New("service config not found")
+
 // Manager 工作空间管理器
 type Manager struct {
 	workDir string
@@ -37,6 +46,17 @@ func (m *Manager) InitWorkspace() error {
 		return fmt.Errorf("failed to create logs directory %s: %w", logsDir, err)
 	}
 
+	// 创建state目录
+	stateDir := filepath.Join(m.workDir, "state")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory %s: %w", stateDir, err)
+	}
+
+	// 创建单元文件历史版本目录
+	if err := os.MkdirAll(m.GetHistoryDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory %s: %w", m.GetHistoryDir(), err)
+	}
+
 	return nil
 }
 
@@ -83,10 +103,146 @@ func (m *Manager) CleanupService(serviceName string) error {
 		return fmt.Errorf("failed to remove log directory %s: %w", logDir, err)
 	}
 
+	// 删除部署配置
+	configFile := m.getConfigFile(serviceName)
+	if err := os.Remove(configFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove service config %s: %w", configFile, err)
+	}
+
 	return nil
 }
 
+// GetReleasesDir 获取服务版本目录路径，每次部署解压到其下的 <release-id> 目录
+func (m *Manager) GetReleasesDir(serviceName string) string {
+	return filepath.Join(m.GetServiceDir(serviceName), "releases")
+}
+
+// GetCurrentDir 获取指向当前版本的符号链接路径，单元的工作目录指向该链接
+func (m *Manager) GetCurrentDir(serviceName string) string {
+	return filepath.Join(m.GetServiceDir(serviceName), "current")
+}
+
+// GetHistoryDir 获取单元文件历史版本目录路径
+func (m *Manager) GetHistoryDir() string {
+	return filepath.Join(m.workDir, "history")
+}
+
 // GetWorkDir 获取工作目录根路径
 func (m *Manager) GetWorkDir() string {
 	return m.workDir
 }
+
+// getConfigFile 获取服务部署配置文件路径
+func (m *Manager) getConfigFile(serviceName string) string {
+	return filepath.Join(m.workDir, "state", serviceName+".json")
+}
+
+// SaveServiceConfig 记录服务部署时使用的配置
+func (m *Manager) SaveServiceConfig(serviceName string, config *hooks.ServiceConfig) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal service config: %w", err)
+	}
+
+	configFile := m.getConfigFile(serviceName)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(configFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write service config %s: %w", configFile, err)
+	}
+	return nil
+}
+
+// LoadServiceConfig 读取服务部署时记录的配置，未记录时返回 ErrConfigNotFound
+func (m *Manager) LoadServiceConfig(serviceName string) (*hooks.ServiceConfig, error) {
+	data, err := os.ReadFile(m.getConfigFile(serviceName))
+	if os.IsNotExist(err) {
+		return nil, ErrConfigNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service config: %w", err)
+	}
+
+	var config hooks.ServiceConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse service config: %w", err)
+	}
+	return &config, nil
+}
+
+// ImportPath 将文件或目录复制到服务目录的 subDir 下，保留文件权限，返回复制后的路径
+// 目标已存在时返回错误，不覆盖已有文件
+func (m *Manager) ImportPath(serviceName, src, subDir string) (string, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", src, err)
+	}
+
+	dst := filepath.Join(m.GetServiceDir(serviceName), subDir, filepath.Base(src))
+	if _, err := os.Lstat(dst); err == nil {
+		return "", fmt.Errorf("import target %s already exists", dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		if err := copyEntry(src, dst, info); err != nil {
+			return "", err
+		}
+		return dst, nil
+	}
+
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return copyEntry(path, filepath.Join(dst, rel), info)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return dst, nil
+}
+
+// copyEntry 复制单个目录、符号链接或普通文件
+func copyEntry(src, dst string, info fs.FileInfo) error {
+	switch {
+	case info.IsDir():
+		return os.MkdirAll(dst, info.Mode().Perm())
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case !info.Mode().IsRegular():
+		// 跳过套接字、设备等特殊文件
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -4,7 +4,11 @@ import (
 	"api-systemd/internal/app"
 	authMiddleware "api-systemd/internal/middleware"
 	"api-systemd/internal/pkg/config"
+	"api-systemd/internal/pkg/events"
 	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/unitpath"
 	"net/http"
 	"time"
 
@@ -13,7 +17,7 @@ import (
 )
 
 // New 创建新的路由器
-func New(cfg *config.Config) *chi.Mux {
+func New(cfg *config.Config, systemdMgr *systemd.Manager, paths *unitpath.Resolver, broker *events.Broker, pol *policy.Policy) *chi.Mux {
 	r := chi.NewRouter()
 
 	// 全局中间件
@@ -22,23 +26,37 @@ func New(cfg *config.Config) *chi.Mux {
 	r.Use(customLogger)
 	r.Use(middleware.Recoverer)
 	r.Use(customCORS)
-	r.Use(middleware.Timeout(60 * time.Second))
-	r.Use(middleware.Compress(5))
 
 	// 认证中间件
 	r.Use(authMiddleware.BearerTokenAuth(cfg))
 
 	// 创建应用实例
-	app := app.New(cfg)
+	app := app.New(cfg, systemdMgr, paths, broker, pol)
 
-	// 设置路由
-	setupRoutes(r, app)
+	// 普通请求设置超时和压缩
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(60 * time.Second))
+		r.Use(middleware.Compress(5))
+
+		// 设置路由
+		setupRoutes(r, app)
+	})
+
+	// 事件流和一次性命令是长连接，不受请求超时和压缩影响
+	setupStreamRoutes(r, app)
 
 	return r
 }
 
+// setupStreamRoutes 设置长连接的事件流和命令输出路由
+func setupStreamRoutes(r chi.Router, app *app.App) {
+	r.Get("/events", app.StreamEvents)
+	r.Get("/events/ws", app.StreamEventsWS)
+	r.Post("/services/{serviceName}/run", app.RunCommand)
+}
+
 // setupRoutes 设置所有路由
-func setupRoutes(r *chi.Mux, app *app.App) {
+func setupRoutes(r chi.Router, app *app.App) {
 	// 服务管理路由组
 	r.Route("/services", func(r chi.Router) {
 		// 获取服务列表
@@ -54,10 +72,51 @@ func setupRoutes(r *chi.Mux, app *app.App) {
 			r.Post("/start", app.StartService)
 			r.Post("/stop", app.Stop)
 			r.Post("/restart", app.Restart)
+			r.Post("/reload", app.Reload)
+			r.Post("/kill", app.Kill)
+			r.Post("/reset-failed", app.ResetFailed)
+			r.Post("/mask", app.Mask)
+			r.Post("/unmask", app.Unmask)
+			r.Post("/adopt", app.Adopt)
+			r.Post("/scale", app.Scale)
+			r.Get("/releases", app.ListReleases)
+			r.Post("/rollback", app.Rollback)
+			r.Patch("/resources", app.UpdateResources)
+			r.Get("/unit", app.GetEffectiveUnit)
+			r.Get("/config", app.GetServiceConfig)
+			r.Get("/dependencies", app.GetDependencies)
+			r.Get("/security", app.GetSecurity)
+			r.Route("/versions", func(r chi.Router) {
+				r.Get("/", app.ListUnitVersions)
+				r.Get("/diff", app.DiffUnitVersions)
+				r.Get("/{versionID}", app.GetUnitVersion)
+				r.Post("/{versionID}/restore", app.RestoreUnitVersion)
+			})
+			r.Route("/dropins", func(r chi.Router) {
+				r.Get("/", app.ListDropIns)
+				r.Post("/", app.CreateDropIn)
+				r.Get("/{dropinName}", app.GetDropIn)
+				r.Put("/{dropinName}", app.UpdateDropIn)
+				r.Delete("/{dropinName}", app.DeleteDropIn)
+			})
 			r.Delete("/", app.Remove)
 		})
 	})
 
+	// 定时任务路由组
+	r.Route("/timers", func(r chi.Router) {
+		r.Get("/", app.ListTimers)
+		r.Post("/deploy", app.DeployTimer)
+		r.Route("/{serviceName}", func(r chi.Router) {
+			r.Get("/", app.GetTimer)
+			r.Post("/trigger", app.TriggerTimer)
+			r.Delete("/", app.RemoveTimer)
+		})
+	})
+
+	// 任务查询
+	r.Get("/jobs/{jobID}", app.GetJob)
+
 	// 配置管理路由组
 	r.Route("/configs", func(r chi.Router) {
 		r.Post("/", app.CreateConfig)
@@ -111,8 +170,8 @@ func customLogger(next http.Handler) http.Handler {
 func customCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, Last-Event-ID")
 		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if r.Method == "OPTIONS" {
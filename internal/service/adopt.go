@@ -0,0 +1,222 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/events"
+	"api-systemd/internal/pkg/hooks"
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"api-systemd/internal/pkg/workspace"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrAlreadyManaged 服务已由本系统管理
+	ErrAlreadyManaged = errors.New("service is already managed")
+	// ErrNotAdoptable 服务无法被接管
+	ErrNotAdoptable = errors.New("service cannot be adopted")
+)
+
+// AdoptRequest 接管已有服务的请求
+type AdoptRequest struct {
+	MoveBinaries bool `json:"move_binaries"` // 将可执行文件复制到工作空间并改写单元
+	Restart      bool `json:"restart"`       // 单元被改写后重启服务使其生效
+}
+
+// AdoptResult 接管结果
+type AdoptResult struct {
+	Service   string               `json:"service"`
+	UnitFile  string               `json:"unit_file"`
+	Rewritten bool                 `json:"rewritten"`        // 是否在单元目录写入了新的单元文件
+	Backup    string               `json:"backup,omitempty"` // 改写前的单元文件备份
+	Config    *hooks.ServiceConfig `json:"config"`
+	Job       *systemd.Job         `json:"job,omitempty"`
+}
+
+// Adopt 将已有的单元纳入管理：解析单元文件并记录为部署配置，之后可像部署的服务一样重新部署
+// 单元文件不在单元目录（如 /usr/lib/systemd/system）或需要复制可执行文件时，在单元目录写入新的单元文件
+// drop-in 不会被合并，仍按原样叠加在新的单元文件上
+func (s *service) Adopt(ctx context.Context, serviceName string, req *AdoptRequest) (result *AdoptResult, err error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Adopt validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.authorize(ctx, systemd.UnitName(serviceName), policy.OpAdopt); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isManagedService(serviceName) {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyManaged, serviceName)
+	}
+
+	// 旧版本部署的服务没有所有权标记，但可能记录了钩子和通知配置
+	saved, err := s.workspaceMgr.LoadServiceConfig(serviceName)
+	if err != nil && !errors.Is(err, workspace.ErrConfigNotFound) {
+		logger.Error(ctx, "Failed to load service config", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to load service config: %w", err)
+	}
+
+	logger.Info(ctx, "Adopting service", "service", serviceName, "move_binaries", req.MoveBinaries)
+
+	uc, err := s.loadUnitConfig(ctx, serviceName, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(uc.Sources) == 0 || uc.Config.ExecStart == "" {
+		return nil, fmt.Errorf("%w: %s has no unit file with ExecStart", ErrNotAdoptable, uc.Unit)
+	}
+	if s.systemdMgr.UserMode() && (uc.Config.User != "" || uc.Config.Group != "") {
+		return nil, fmt.Errorf("%w: %w", ErrNotAdoptable, ErrUserNotSupported)
+	}
+
+	config := uc.Config
+	if saved != nil {
+		mergeSavedConfig(config, saved)
+	}
+	unitFile := s.paths.Service(serviceName)
+	config.ExtraOptions = uc.Extra
+	for _, target := range uc.WantedBy {
+		if target != s.installTarget(config) {
+			config.ExtraOptions = append(config.ExtraOptions, UnitOption{Section: "Install", Name: "WantedBy", Value: target})
+		}
+	}
+
+	// 失败时 result 为 nil，事件只使用局部变量
+	rewritten := uc.Sources[0] != unitFile || req.MoveBinaries
+	defer func() {
+		data := map[string]interface{}{"phase": "completed", "rewritten": rewritten}
+		if err != nil {
+			data["phase"] = "failed"
+			data["error"] = err.Error()
+		}
+		s.events.Publish(events.EventAdopt, serviceName, data)
+	}()
+
+	result = &AdoptResult{
+		Service:   serviceName,
+		UnitFile:  unitFile,
+		Rewritten: rewritten,
+		Config:    config,
+	}
+
+	if rewritten {
+		serviceDir, err := s.workspaceMgr.EnsureServiceDir(serviceName)
+		if err != nil {
+			logger.Error(ctx, "Failed to create service directory", "error", err, "service", serviceName)
+			return nil, fmt.Errorf("failed to create service directory: %w", err)
+		}
+
+		if req.MoveBinaries {
+			if err := s.importBinaries(ctx, serviceName, config); err != nil {
+				return nil, err
+			}
+		}
+
+		// 备份原单元文件，改写单元目录下的文件前可用于恢复
+		original, err := os.ReadFile(uc.Sources[0])
+		if err != nil {
+			logger.Error(ctx, "Failed to read unit file", "error", err, "file", uc.Sources[0])
+			return nil, fmt.Errorf("failed to read unit file: %w", err)
+		}
+		result.Backup = filepath.Join(serviceDir, uc.Unit+".orig")
+		if err := os.WriteFile(result.Backup, original, 0644); err != nil {
+			logger.Error(ctx, "Failed to back up unit file", "error", err, "file", result.Backup)
+			return nil, fmt.Errorf("failed to back up unit file: %w", err)
+		}
+
+		// 复制可执行文件会改变工作目录，配置全部确定后再渲染
+		if err := s.writeUnit(unitFile, s.newSystemdConfig(serviceName, "", config)); err != nil {
+			logger.Error(ctx, "Failed to write systemd config", "error", err, "file", unitFile)
+			return nil, fmt.Errorf("failed to write systemd config: %w", err)
+		}
+	} else if err := s.writeManagedDropIn(uc.Unit); err != nil {
+		// 保留手工编写的单元文件，通过 drop-in 携带所有权标记
+		logger.Error(ctx, "Failed to write managed drop-in", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to write managed drop-in: %w", err)
+	}
+
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return nil, fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+
+	if err := s.workspaceMgr.SaveServiceConfig(serviceName, config); err != nil {
+		logger.Error(ctx, "Failed to save service config", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to save service config: %w", err)
+	}
+
+	if rewritten && req.Restart {
+		job, err := s.runJob(ctx, serviceName, "restart", JobOptions{Wait: true})
+		if err != nil {
+			logger.Error(ctx, "Failed to restart service", "error", err, "service", serviceName)
+			return nil, fmt.Errorf("failed to restart service: %w", err)
+		}
+		result.Job = job
+	}
+
+	logger.Info(ctx, "Service adopted successfully", "service", serviceName, "rewritten", rewritten)
+	return result, nil
+}
+
+// importBinaries 将 ExecStart 的可执行文件复制到服务目录并更新配置
+// 可执行文件位于工作目录下时复制整个工作目录，否则只复制可执行文件到 bin 目录
+func (s *service) importBinaries(ctx context.Context, serviceName string, config *hooks.ServiceConfig) error {
+	binary, err := execPath(config.ExecStart)
+	if err != nil || !filepath.IsAbs(binary) {
+		return fmt.Errorf("%w: cannot locate executable in ExecStart %q", ErrNotAdoptable, config.ExecStart)
+	}
+
+	workDir := strings.TrimPrefix(config.WorkingDirectory, "-")
+	if importableDir(workDir) && strings.HasPrefix(binary, workDir+string(filepath.Separator)) {
+		dst, err := s.workspaceMgr.ImportPath(serviceName, workDir, "")
+		if err != nil {
+			logger.Error(ctx, "Failed to import working directory", "error", err, "dir", workDir)
+			return fmt.Errorf("failed to import working directory: %w", err)
+		}
+		config.WorkingDirectory = dst
+		config.ExecStart = strings.Replace(config.ExecStart, binary, filepath.Join(dst, strings.TrimPrefix(binary, workDir)), 1)
+		logger.Info(ctx, "Imported working directory", "service", serviceName, "from", workDir, "to", dst)
+		return nil
+	}
+
+	dst, err := s.workspaceMgr.ImportPath(serviceName, binary, "bin")
+	if err != nil {
+		logger.Error(ctx, "Failed to import executable", "error", err, "file", binary)
+		return fmt.Errorf("failed to import executable: %w", err)
+	}
+	config.ExecStart = strings.Replace(config.ExecStart, binary, dst, 1)
+	if config.WorkingDirectory == "" {
+		config.WorkingDirectory = filepath.Dir(dst)
+	}
+	logger.Info(ctx, "Imported executable", "service", serviceName, "from", binary, "to", dst)
+	return nil
+}
+
+// execPath 返回 Exec 命令行中的可执行文件路径，忽略 -、@、:、+、! 前缀
+func execPath(command string) (string, error) {
+	words, err := SplitUnitWords(command)
+	if err != nil {
+		return "", err
+	}
+	if len(words) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	return strings.TrimLeft(words[0], "-@:+!"), nil
+}
+
+// importableDir 判断工作目录能否整体复制，根目录和 /usr、/opt 等顶层目录不复制
+func importableDir(dir string) bool {
+	if !filepath.IsAbs(dir) {
+		return false
+	}
+	return strings.Count(filepath.Clean(dir), string(filepath.Separator)) >= 2
+}
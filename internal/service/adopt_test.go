@@ -0,0 +1,152 @@
+package service
+
+import (
+	"testing"
+
+	"api-systemd/internal/pkg/hooks"
+)
+
+func TestExecPath(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+		wantErr bool
+	}{
+		{command: "/usr/bin/app --flag", want: "/usr/bin/app"},
+		{command: "-/usr/bin/app", want: "/usr/bin/app"},
+		{command: "@/usr/bin/app app-name", want: "/usr/bin/app"},
+		{command: "+!/opt/app/bin/run", want: "/opt/app/bin/run"},
+		{command: `"/opt/my app/run" --x`, want: "/opt/my app/run"},
+		{command: "app", want: "app"},
+		{command: "", wantErr: true},
+		{command: `"/unterminated`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := execPath(tt.command)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("execPath(%q) = %q, want error", tt.command, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("execPath(%q) = %q, %v, want %q", tt.command, got, err, tt.want)
+		}
+	}
+}
+
+func TestImportableDir(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{"/opt/app", true},
+		{"/srv/app/current", true},
+		{"/opt/app/", true},
+		{"/", false},
+		{"/usr", false},
+		{"/opt", false},
+		{"relative/dir", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := importableDir(tt.dir); got != tt.want {
+			t.Errorf("importableDir(%q) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestMergeSavedConfig(t *testing.T) {
+	config := &hooks.ServiceConfig{
+		Hooks: []hooks.Hook{{Type: hooks.HookPreStart, Command: "/usr/bin/migrate"}},
+	}
+	saved := &hooks.ServiceConfig{
+		HealthCheck: &hooks.HealthCheckConfig{},
+		Hooks: []hooks.Hook{
+			// 已渲染为 ExecStartPre，以单元文件为准
+			{Type: hooks.HookPreStart, Command: "/usr/bin/old-migrate"},
+			// 回调钩子不在单元文件中，需要保留
+			{Type: hooks.HookPostStart, CallbackURL: "http://example.com/hook"},
+			// 不会渲染为 Exec 配置项的命令钩子
+			{Type: hooks.HookOnFailure, Command: "/usr/bin/alert"},
+		},
+	}
+
+	mergeSavedConfig(config, saved)
+
+	if config.HealthCheck != saved.HealthCheck {
+		t.Error("health check not merged")
+	}
+	want := []hooks.HookType{hooks.HookPreStart, hooks.HookPostStart, hooks.HookOnFailure}
+	if len(config.Hooks) != len(want) {
+		t.Fatalf("hooks = %+v, want types %v", config.Hooks, want)
+	}
+	for i, hookType := range want {
+		if config.Hooks[i].Type != hookType {
+			t.Errorf("hooks[%d].Type = %s, want %s", i, config.Hooks[i].Type, hookType)
+		}
+	}
+	if config.Hooks[0].Command != "/usr/bin/migrate" {
+		t.Errorf("unit file hook replaced by saved hook: %q", config.Hooks[0].Command)
+	}
+}
+
+func TestNewUnitConfig(t *testing.T) {
+	options := []UnitOption{
+		{Section: "Unit", Name: "Description", Value: "Legacy App"},
+		{Section: "Unit", Name: ManagedKey, Value: "yes"},
+		{Section: "Unit", Name: "After", Value: "network.target"},
+		{Section: "Service", Name: "ExecStart", Value: "/opt/app/run --a"},
+		{Section: "Service", Name: "ExecStart", Value: "/opt/app/second"},
+		{Section: "Service", Name: "ExecStartPre", Value: "/opt/app/migrate"},
+		{Section: "Service", Name: "Environment", Value: "A=1 \"B=two words\""},
+		{Section: "Service", Name: "Environment", Value: "C=3"},
+		{Section: "Service", Name: "RestartSec", Value: "1min 30s"},
+		{Section: "Service", Name: "TasksMax", Value: "infinity"},
+		{Section: "Service", Name: "LimitCORE", Value: "0"},
+		{Section: "Install", Name: "WantedBy", Value: "multi-user.target graphical.target"},
+	}
+
+	uc := NewUnitConfig("legacy.service", options)
+	config := uc.Config
+
+	if config.ServiceName != "legacy" || config.Description != "Legacy App" {
+		t.Errorf("name/description = %q/%q", config.ServiceName, config.Description)
+	}
+	if config.ExecStart != "/opt/app/run --a" {
+		t.Errorf("ExecStart = %q, want the first command", config.ExecStart)
+	}
+	if len(config.Hooks) != 1 || config.Hooks[0].Type != hooks.HookPreStart || config.Hooks[0].Command != "/opt/app/migrate" {
+		t.Errorf("hooks = %+v", config.Hooks)
+	}
+	wantEnv := map[string]string{"A": "1", "B": "two words", "C": "3"}
+	if len(config.Environment) != len(wantEnv) {
+		t.Errorf("environment = %v, want %v", config.Environment, wantEnv)
+	}
+	for k, v := range wantEnv {
+		if config.Environment[k] != v {
+			t.Errorf("environment[%s] = %q, want %q", k, config.Environment[k], v)
+		}
+	}
+	if config.RestartDelaySec.Seconds() != 90 {
+		t.Errorf("RestartDelaySec = %v, want 90s", config.RestartDelaySec)
+	}
+	if config.InstallTarget != "multi-user.target" || len(uc.WantedBy) != 2 {
+		t.Errorf("install target = %q, wanted by = %v", config.InstallTarget, uc.WantedBy)
+	}
+
+	// 第二条 ExecStart、无法解析的 TasksMax 和未建模的 LimitCORE 保留在 Extra 中，所有权标记不保留
+	var extra []string
+	for _, opt := range uc.Extra {
+		extra = append(extra, opt.Name)
+	}
+	want := []string{"ExecStart", "TasksMax", "LimitCORE"}
+	if len(extra) != len(want) {
+		t.Fatalf("extra = %v, want %v", extra, want)
+	}
+	for i := range want {
+		if extra[i] != want[i] {
+			t.Errorf("extra[%d] = %s, want %s", i, extra[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"context"
+	"fmt"
+	"os"
+)
+
+// Kill 向服务进程发送信号，signal 为空时发送 SIGTERM，target 为空时发送给所有进程
+func (s *service) Kill(ctx context.Context, serviceName, signal, target string) error {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
+		logger.Error(ctx, "Kill validation failed", "error", err, "service", serviceName)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpKill); err != nil {
+		return err
+	}
+
+	if signal == "" {
+		signal = "SIGTERM"
+	}
+	sig, err := systemd.ParseSignal(signal)
+	if err != nil {
+		logger.Error(ctx, "Kill validation failed", "error", err, "service", serviceName)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	who, err := systemd.ParseKillTarget(target)
+	if err != nil {
+		logger.Error(ctx, "Kill validation failed", "error", err, "service", serviceName)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	logger.Info(ctx, "Sending signal to service", "service", serviceName, "signal", sig.String(), "target", who)
+
+	if err := s.systemdMgr.KillUnit(ctx, serviceName, who, sig); err != nil {
+		logger.Error(ctx, "Failed to kill service", "error", err, "service", serviceName)
+		return fmt.Errorf("failed to kill service: %w", err)
+	}
+
+	logger.Info(ctx, "Signal sent successfully", "service", serviceName, "signal", sig.String(), "target", who)
+	return nil
+}
+
+// ResetFailed 清除服务的失败状态和启动频率限制计数
+func (s *service) ResetFailed(ctx context.Context, serviceName string) error {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
+		logger.Error(ctx, "ResetFailed validation failed", "error", err, "service", serviceName)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpResetFailed); err != nil {
+		return err
+	}
+
+	logger.Info(ctx, "Resetting failed state", "service", serviceName)
+
+	if err := s.systemdMgr.ResetFailedUnit(ctx, serviceName); err != nil {
+		logger.Error(ctx, "Failed to reset failed state", "error", err, "service", serviceName)
+		return fmt.Errorf("failed to reset failed state: %w", err)
+	}
+
+	logger.Info(ctx, "Failed state reset successfully", "service", serviceName)
+	return nil
+}
+
+// Mask 屏蔽服务，使其无法被手动或依赖启动
+// 受管服务的单元文件位于持久的单元目录，因此屏蔽链接写入运行时目录，重启后失效
+func (s *service) Mask(ctx context.Context, serviceName string) error {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Mask validation failed", "error", err, "service", serviceName)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpMask); err != nil {
+		return err
+	}
+	// 单元目录本身位于运行时目录时，屏蔽链接会与单元文件冲突
+	if s.paths.Runtime() {
+		if _, err := os.Stat(s.paths.Service(serviceName)); err == nil {
+			return fmt.Errorf("%w: %s is a runtime unit, stop or remove it instead of masking", systemd.ErrActionNotApplicable, systemd.UnitName(serviceName))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logger.Info(ctx, "Masking service", "service", serviceName)
+
+	if err := s.systemdMgr.MaskUnit(ctx, serviceName, true); err != nil {
+		logger.Error(ctx, "Failed to mask service", "error", err, "service", serviceName)
+		return fmt.Errorf("failed to mask service: %w", err)
+	}
+
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+
+	logger.Info(ctx, "Service masked successfully", "service", serviceName)
+	return nil
+}
+
+// Unmask 取消服务屏蔽
+func (s *service) Unmask(ctx context.Context, serviceName string) error {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Unmask validation failed", "error", err, "service", serviceName)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpUnmask); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logger.Info(ctx, "Unmasking service", "service", serviceName)
+
+	if err := s.systemdMgr.UnmaskUnit(ctx, serviceName, true); err != nil {
+		logger.Error(ctx, "Failed to unmask service", "error", err, "service", serviceName)
+		return fmt.Errorf("failed to unmask service: %w", err)
+	}
+
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+
+	logger.Info(ctx, "Service unmasked successfully", "service", serviceName)
+	return nil
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"context"
+	"fmt"
+)
+
+// GetDependencies 获取服务的依赖图，depth 为遍历深度，0 表示使用默认深度
+func (s *service) GetDependencies(ctx context.Context, serviceName string, depth int) (*systemd.DependencyGraph, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetDependencies validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	graph, err := s.systemdMgr.Dependencies(ctx, serviceName, depth)
+	if err != nil {
+		logger.Error(ctx, "Failed to load dependencies", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+	return graph, nil
+}
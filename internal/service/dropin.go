@@ -0,0 +1,310 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDropInNotFound drop-in 文件不存在
+	ErrDropInNotFound = errors.New("drop-in not found")
+	// ErrDropInExists drop-in 文件已存在
+	ErrDropInExists = errors.New("drop-in already exists")
+	// ErrEmptyDropIn drop-in 内容为空
+	ErrEmptyDropIn = errors.New("drop-in content cannot be empty")
+)
+
+// DropIn 单元的 drop-in 覆盖配置
+type DropIn struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Content string    `json:"content,omitempty"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// UnitFile 单元文件或 drop-in 的路径和内容
+type UnitFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// EffectiveUnit 基础单元与全部 drop-in 合并后的视图
+type EffectiveUnit struct {
+	Unit     string     `json:"unit"`
+	Fragment *UnitFile  `json:"fragment,omitempty"` // 基础单元文件，可能位于 /usr/lib/systemd/system
+	DropIns  []UnitFile `json:"drop_ins"`           // 按 systemd 应用顺序排列
+	Merged   string     `json:"merged"`             // 类似 systemctl cat 的合并内容
+}
+
+// dropInDir 返回单元在单元目录下的 drop-in 目录
+func (s *service) dropInDir(serviceName string) string {
+	return s.paths.DropInDir(systemd.UnitName(serviceName))
+}
+
+// ListDropIns 列出单元在单元目录下的 drop-in
+func (s *service) ListDropIns(ctx context.Context, serviceName string) ([]DropIn, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "ListDropIns validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dir := s.dropInDir(serviceName)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []DropIn{}, nil
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to read drop-in directory", "error", err, "dir", dir)
+		return nil, fmt.Errorf("failed to read drop-in directory: %w", err)
+	}
+
+	dropIns := []DropIn{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".conf") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		dropIns = append(dropIns, DropIn{
+			Name:    entry.Name(),
+			Path:    filepath.Join(dir, entry.Name()),
+			ModTime: info.ModTime(),
+		})
+	}
+	return dropIns, nil
+}
+
+// GetDropIn 读取 drop-in 内容
+func (s *service) GetDropIn(ctx context.Context, serviceName, name string) (*DropIn, error) {
+	if err := validateDropIn(serviceName, name); err != nil {
+		logger.Error(ctx, "GetDropIn validation failed", "error", err, "service", serviceName, "dropin", name)
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.readDropIn(serviceName, name)
+}
+
+// CreateDropIn 创建 drop-in，已存在时返回 ErrDropInExists
+func (s *service) CreateDropIn(ctx context.Context, serviceName, name, content string) (*DropIn, error) {
+	return s.writeDropIn(ctx, serviceName, name, content, true)
+}
+
+// UpdateDropIn 更新 drop-in，不存在时返回 ErrDropInNotFound
+func (s *service) UpdateDropIn(ctx context.Context, serviceName, name, content string) (*DropIn, error) {
+	return s.writeDropIn(ctx, serviceName, name, content, false)
+}
+
+// writeDropIn 写入 drop-in 并重新加载 systemd
+func (s *service) writeDropIn(ctx context.Context, serviceName, name, content string, create bool) (*DropIn, error) {
+	if err := validateDropIn(serviceName, name); err != nil {
+		logger.Error(ctx, "Drop-in validation failed", "error", err, "service", serviceName, "dropin", name)
+		return nil, err
+	}
+	if err := checkReservedDropIn(name); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, systemd.UnitName(serviceName), policy.OpDropIn); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("validation failed: %w", ErrEmptyDropIn)
+	}
+	if err := ValidateUnitContent(content, false); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 只为 systemd 已知的单元创建覆盖配置
+	if _, err := s.systemdMgr.Load(ctx, serviceName); err != nil {
+		logger.Error(ctx, "Failed to load unit", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to load unit: %w", err)
+	}
+
+	dir := s.dropInDir(serviceName)
+	path := filepath.Join(dir, name)
+	_, statErr := os.Stat(path)
+	if create && statErr == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDropInExists, path)
+	}
+	if !create && os.IsNotExist(statErr) {
+		return nil, fmt.Errorf("%w: %s", ErrDropInNotFound, path)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Error(ctx, "Failed to create drop-in directory", "error", err, "dir", dir)
+		return nil, fmt.Errorf("failed to create drop-in directory: %w", err)
+	}
+
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	logger.Info(ctx, "Writing drop-in", "service", serviceName, "file", path)
+	if err := s.units.WriteFile(path, []byte(content), 0644); err != nil {
+		logger.Error(ctx, "Failed to write drop-in", "error", err, "file", path)
+		return nil, fmt.Errorf("failed to write drop-in: %w", err)
+	}
+
+	logger.Info(ctx, "Reloading systemd daemon")
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return nil, fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+
+	return s.readDropIn(serviceName, name)
+}
+
+// DeleteDropIn 删除 drop-in 并重新加载 systemd
+func (s *service) DeleteDropIn(ctx context.Context, serviceName, name string) error {
+	if err := validateDropIn(serviceName, name); err != nil {
+		logger.Error(ctx, "DeleteDropIn validation failed", "error", err, "service", serviceName, "dropin", name)
+		return err
+	}
+	if err := checkReservedDropIn(name); err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, systemd.UnitName(serviceName), policy.OpDropIn); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.dropInDir(serviceName)
+	path := filepath.Join(dir, name)
+
+	logger.Info(ctx, "Removing drop-in", "service", serviceName, "file", path)
+	if err := s.units.Remove(path); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrDropInNotFound, path)
+	} else if err != nil {
+		logger.Error(ctx, "Failed to remove drop-in", "error", err, "file", path)
+		return fmt.Errorf("failed to remove drop-in: %w", err)
+	}
+
+	// 目录为空时一并删除，失败说明仍有其他文件
+	_ = os.Remove(dir)
+
+	logger.Info(ctx, "Reloading systemd daemon")
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+
+	return nil
+}
+
+// GetEffectiveUnit 返回基础单元文件及 systemd 实际应用的全部 drop-in
+func (s *service) GetEffectiveUnit(ctx context.Context, serviceName string) (*EffectiveUnit, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetEffectiveUnit validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	unit, err := s.systemdMgr.Load(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "Failed to load unit", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to load unit: %w", err)
+	}
+
+	effective := &EffectiveUnit{
+		Unit:    unit.Name,
+		DropIns: []UnitFile{},
+	}
+
+	var merged strings.Builder
+	appendFile := func(path string) (*UnitFile, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if merged.Len() > 0 {
+			merged.WriteString("\n")
+		}
+		fmt.Fprintf(&merged, "# %s\n%s", path, data)
+		return &UnitFile{Path: path, Content: string(data)}, nil
+	}
+
+	if unit.FragmentPath != "" {
+		fragment, err := appendFile(unit.FragmentPath)
+		if err != nil {
+			logger.Error(ctx, "Failed to read unit file", "error", err, "file", unit.FragmentPath)
+			return nil, fmt.Errorf("failed to read unit file: %w", err)
+		}
+		effective.Fragment = fragment
+	}
+
+	// DropInPaths 已按 systemd 的应用顺序排列
+	for _, path := range unit.DropInPaths {
+		dropIn, err := appendFile(path)
+		if err != nil {
+			logger.Warn(ctx, "Failed to read drop-in", "error", err, "file", path)
+			continue
+		}
+		effective.DropIns = append(effective.DropIns, *dropIn)
+	}
+
+	effective.Merged = merged.String()
+	return effective, nil
+}
+
+// validateDropIn 验证服务名和 drop-in 文件名
+func validateDropIn(serviceName, name string) error {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := validator.ValidateDropInName(name); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
+
+// checkReservedDropIn 所有权标记 drop-in 由接管流程维护，不允许通过接口修改或删除
+func checkReservedDropIn(name string) error {
+	if name == managedDropIn {
+		return fmt.Errorf("validation failed: %w: %s is reserved", validator.ErrInvalidDropInName, name)
+	}
+	return nil
+}
+
+// readDropIn 读取 drop-in 文件
+func (s *service) readDropIn(serviceName, name string) (*DropIn, error) {
+	path := filepath.Join(s.dropInDir(serviceName), name)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrDropInNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat drop-in: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read drop-in: %w", err)
+	}
+
+	return &DropIn{
+		Name:    name,
+		Path:    path,
+		Content: string(data),
+		ModTime: info.ModTime(),
+	}, nil
+}
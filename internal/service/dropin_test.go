@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"api-systemd/internal/pkg/validator"
+)
+
+func TestValidateDropIn(t *testing.T) {
+	tests := []struct {
+		service, name string
+		wantErr       error
+	}{
+		{"app", "10-limits.conf", nil},
+		{"app", "override.conf", nil},
+		{"app", "a.b_c-d.conf", nil},
+		{"app", "override", validator.ErrInvalidDropInName},
+		{"app", ".hidden.conf", validator.ErrInvalidDropInName},
+		{"app", "../escape.conf", validator.ErrInvalidDropInName},
+		{"app", "dir/nested.conf", validator.ErrInvalidDropInName},
+		{"app", "", validator.ErrInvalidDropInName},
+		{"", "override.conf", validator.ErrEmptyServiceName},
+	}
+	for _, tt := range tests {
+		err := validateDropIn(tt.service, tt.name)
+		if tt.wantErr == nil && err != nil {
+			t.Errorf("validateDropIn(%q, %q) = %v, want nil", tt.service, tt.name, err)
+		}
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("validateDropIn(%q, %q) = %v, want %v", tt.service, tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckReservedDropIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		reserved bool
+	}{
+		{managedDropIn, true},
+		{"override.conf", false},
+		{"00-api-systemd.conf", false},
+	}
+	for _, tt := range tests {
+		err := checkReservedDropIn(tt.name)
+		if reserved := err != nil; reserved != tt.reserved {
+			t.Errorf("checkReservedDropIn(%q) = %v, want reserved %v", tt.name, err, tt.reserved)
+		}
+		if err != nil && !errors.Is(err, validator.ErrInvalidDropInName) {
+			t.Errorf("checkReservedDropIn(%q) = %v, want ErrInvalidDropInName", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/hooks"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrProtectHome 显式设置的 ProtectHome= 会隐藏位于家目录下的工作目录
+var ErrProtectHome = errors.New("protect_home hides the service directory under the home directory, use read-only")
+
+// hardeningPresets 加固预设展开的默认选项
+var hardeningPresets = map[string]hooks.HardeningConfig{
+	hooks.HardeningStandard: {
+		ProtectSystem:           "full",
+		ProtectHome:             "read-only",
+		PrivateTmp:              boolPtr(true),
+		NoNewPrivileges:         boolPtr(true),
+		PrivateDevices:          boolPtr(true),
+		RestrictAddressFamilies: []string{"AF_UNIX", "AF_INET", "AF_INET6"},
+	},
+	hooks.HardeningStrict: {
+		ProtectSystem:           "strict",
+		ProtectHome:             "yes",
+		PrivateTmp:              boolPtr(true),
+		NoNewPrivileges:         boolPtr(true),
+		PrivateDevices:          boolPtr(true),
+		RestrictAddressFamilies: []string{"AF_UNIX", "AF_INET", "AF_INET6"},
+		CapabilityBoundingSet:   []string{},
+		SystemCallFilter:        []string{"@system-service"},
+	},
+}
+
+// homeDirs ProtectHome= 作用的目录
+var homeDirs = []string{"/home", "/root", "/run/user"}
+
+// underHome 判断是否有路径位于 ProtectHome= 作用的目录下
+func underHome(paths []string) bool {
+	for _, path := range paths {
+		for _, dir := range homeDirs {
+			if path == dir || strings.HasPrefix(path, dir+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkProtectHome 拒绝显式设置会隐藏家目录下服务目录的 ProtectHome=，预设值由 hardeningOptions 自动降为 read-only
+func checkProtectHome(h *hooks.HardeningConfig, rwPaths []string) error {
+	if h == nil || (h.ProtectHome != "yes" && h.ProtectHome != "tmpfs") || !underHome(rwPaths) {
+		return nil
+	}
+	return fmt.Errorf("%w: protect_home=%s", ErrProtectHome, h.ProtectHome)
+}
+
+// hardeningOptions 将加固配置展开为 [Service] 配置项，rwPaths 为服务需要写入的目录
+// 预设为 none 且未设置任何字段时不生成配置项
+func hardeningOptions(h *hooks.HardeningConfig, rwPaths []string) []UnitOption {
+	if h == nil {
+		return nil
+	}
+
+	// 以预设为基础，设置的字段覆盖预设值
+	resolved := hardeningPresets[h.Profile]
+	if h.ProtectSystem != "" {
+		resolved.ProtectSystem = h.ProtectSystem
+	}
+	if h.ProtectHome != "" {
+		resolved.ProtectHome = h.ProtectHome
+	}
+	if h.PrivateTmp != nil {
+		resolved.PrivateTmp = h.PrivateTmp
+	}
+	if h.NoNewPrivileges != nil {
+		resolved.NoNewPrivileges = h.NoNewPrivileges
+	}
+	if h.PrivateDevices != nil {
+		resolved.PrivateDevices = h.PrivateDevices
+	}
+	if h.RestrictAddressFamilies != nil {
+		resolved.RestrictAddressFamilies = h.RestrictAddressFamilies
+	}
+	if h.CapabilityBoundingSet != nil {
+		resolved.CapabilityBoundingSet = h.CapabilityBoundingSet
+	}
+	if h.SystemCallFilter != nil {
+		resolved.SystemCallFilter = h.SystemCallFilter
+	}
+	resolved.DynamicUser = h.DynamicUser
+
+	// 工作空间位于家目录下时（如用户模式的 ~/.local/share），隐藏家目录会使服务无法执行自己的程序，
+	// ReadWritePaths= 也无法放行被隐藏的目录，预设值改为只读后再放行写入目录；显式设置的值由 checkProtectHome 拒绝
+	if h.ProtectHome == "" && (resolved.ProtectHome == "yes" || resolved.ProtectHome == "tmpfs") && underHome(rwPaths) {
+		resolved.ProtectHome = "read-only"
+	}
+
+	var options []UnitOption
+	add := func(name, value string) {
+		options = append(options, UnitOption{Section: "Service", Name: name, Value: value})
+	}
+	addBool := func(name string, value *bool) {
+		if value != nil {
+			add(name, yesNo(*value))
+		}
+	}
+
+	addBool("DynamicUser", resolved.DynamicUser)
+	if resolved.ProtectSystem != "" {
+		add("ProtectSystem", resolved.ProtectSystem)
+	}
+	if resolved.ProtectHome != "" {
+		add("ProtectHome", resolved.ProtectHome)
+	}
+	addBool("PrivateTmp", resolved.PrivateTmp)
+	addBool("NoNewPrivileges", resolved.NoNewPrivileges)
+	addBool("PrivateDevices", resolved.PrivateDevices)
+	if len(resolved.RestrictAddressFamilies) > 0 {
+		add("RestrictAddressFamilies", strings.Join(resolved.RestrictAddressFamilies, " "))
+	}
+	if resolved.CapabilityBoundingSet != nil {
+		add("CapabilityBoundingSet", strings.Join(resolved.CapabilityBoundingSet, " "))
+	}
+	if len(resolved.SystemCallFilter) > 0 {
+		add("SystemCallFilter", strings.Join(resolved.SystemCallFilter, " "))
+	}
+
+	// 没有任何沙箱选项时无需放行写入目录
+	if len(options) == 0 && len(h.ReadWritePaths) == 0 {
+		return nil
+	}
+	for _, path := range append(rwPaths, h.ReadWritePaths...) {
+		if path != "" {
+			add("ReadWritePaths", path)
+		}
+	}
+	return options
+}
+
+// yesNo 将布尔值转换为单元文件取值
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// boolPtr 返回布尔值指针
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/events"
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 所有权标记，systemd 会忽略 X- 开头的配置项
+const (
+	ManagedKey     = "X-ApiSystemd-Managed"
+	VersionKey     = "X-ApiSystemd-Version"
+	ManagedVersion = "1" // 标记格式版本
+
+	// managedDropIn 接管但未改写的单元通过该 drop-in 携带标记
+	managedDropIn = "00-api-systemd-managed.conf"
+	// managedMarker 写入单元 [Unit] 小节的标记
+	managedMarker = ManagedKey + "=yes\n" + VersionKey + "=" + ManagedVersion
+)
+
+// ErrUnmanaged 单元不是由本系统部署或接管的
+var ErrUnmanaged = errors.New("unit is not managed by api-systemd")
+
+// managedSet 受管单元名的内存集合，单元状态信号回调据此判断，不读取磁盘
+// 启动时扫描单元目录，之后通过单元写入器写入或删除文件时按单元刷新
+type managedSet struct {
+	mu    sync.RWMutex
+	units map[string]bool
+}
+
+// loadManagedUnits 扫描单元目录中的单元文件和 drop-in 目录，建立受管单元集合
+func (s *service) loadManagedUnits() {
+	entries, err := os.ReadDir(s.paths.Dir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			if !strings.HasSuffix(name, ".d") {
+				continue
+			}
+			name = strings.TrimSuffix(name, ".d")
+		}
+		s.refreshManaged(name)
+	}
+}
+
+// unitFileChanged 单元目录下的文件被写入或删除后刷新所属单元的受管状态
+func (s *service) unitFileChanged(path string) {
+	rel, err := filepath.Rel(s.paths.Dir(), path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return
+	}
+	unit := strings.SplitN(rel, string(filepath.Separator), 2)[0]
+	s.refreshManaged(strings.TrimSuffix(unit, ".d"))
+}
+
+// refreshManaged 按磁盘上的单元文件和标记 drop-in 更新单元的受管状态
+func (s *service) refreshManaged(unitName string) {
+	managed := s.hasOwnMarker(unitName)
+
+	s.managed.mu.Lock()
+	defer s.managed.mu.Unlock()
+	if managed {
+		s.managed.units[unitName] = true
+	} else {
+		delete(s.managed.units, unitName)
+	}
+}
+
+// isManagedCached 按内存集合判断单元是否受管，模板实例按其模板判断
+func (s *service) isManagedCached(unitName string) bool {
+	s.managed.mu.RLock()
+	defer s.managed.mu.RUnlock()
+	if s.managed.units[unitName] {
+		return true
+	}
+	template := systemd.TemplateName(unitName)
+	return template != "" && template != unitName && s.managed.units[template]
+}
+
+// isManagedService 判断服务是否由本系统管理
+func (s *service) isManagedService(serviceName string) bool {
+	return s.isManagedUnit(systemd.UnitName(serviceName))
+}
+
+// isManagedUnit 判断单元目录下的单元文件或其标记 drop-in 是否带有所有权标记
+// 模板实例（name@1.service）没有自己的单元文件，按其模板判断
+func (s *service) isManagedUnit(unitName string) bool {
+	if s.hasOwnMarker(unitName) {
+		return true
+	}
+	if template := systemd.TemplateName(unitName); template != "" && template != unitName {
+		return s.isManagedUnit(template)
+	}
+	return false
+}
+
+// hasOwnMarker 判断单元自身的单元文件或标记 drop-in 是否带有所有权标记
+func (s *service) hasOwnMarker(unitName string) bool {
+	return hasManagedMarker(s.paths.Unit(unitName)) ||
+		hasManagedMarker(filepath.Join(s.paths.DropInDir(unitName), managedDropIn))
+}
+
+// hasManagedMarker 判断文件的 [Unit] 小节是否带有所有权标记
+func hasManagedMarker(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	return containsManagedMarker(file)
+}
+
+// containsManagedMarker 判断单元内容的 [Unit] 小节是否带有所有权标记
+func containsManagedMarker(r io.Reader) bool {
+	options, err := ParseUnitFile(r)
+	if err != nil {
+		return false
+	}
+	for _, opt := range options {
+		if opt.Section == "Unit" && opt.Name == ManagedKey && opt.Value == "yes" {
+			return true
+		}
+	}
+	return false
+}
+
+// isMarkerOption 判断配置项是否为所有权标记，解析单元时不计入 Extra
+func isMarkerOption(opt UnitOption) bool {
+	return opt.Section == "Unit" && strings.HasPrefix(opt.Name, "X-ApiSystemd-")
+}
+
+// writeManagedDropIn 为未改写的单元写入带所有权标记的 drop-in
+func (s *service) writeManagedDropIn(unitName string) error {
+	dir := s.paths.DropInDir(unitName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create drop-in directory: %w", err)
+	}
+	content := "# Managed by api-systemd, do not remove\n[Unit]\n" + managedMarker + "\n"
+	return s.units.WriteFile(filepath.Join(dir, managedDropIn), []byte(content), 0644)
+}
+
+// checkOperation 检查策略并拒绝对非受管服务执行变更操作
+func (s *service) checkOperation(ctx context.Context, serviceName string, op policy.Operation) error {
+	return s.checkUnitOperation(ctx, systemd.UnitName(serviceName), op)
+}
+
+// checkUnitOperation 检查策略并拒绝对非受管单元执行变更操作，配置允许时放行非受管单元
+func (s *service) checkUnitOperation(ctx context.Context, unitName string, op policy.Operation) error {
+	if err := s.authorize(ctx, unitName, op); err != nil {
+		return err
+	}
+	if s.allowUnmanaged || s.isManagedUnit(unitName) {
+		return nil
+	}
+	logger.Warn(ctx, "Refusing operation on unmanaged unit", "unit", unitName, "operation", op)
+	return fmt.Errorf("%w: %s", ErrUnmanaged, unitName)
+}
+
+// checkDeployTarget 检查部署策略，并拒绝覆盖单元目录下非本系统创建的同名单元文件
+func (s *service) checkDeployTarget(ctx context.Context, unitName string) error {
+	if err := s.authorize(ctx, unitName, policy.OpDeploy); err != nil {
+		return err
+	}
+	if s.allowUnmanaged || s.isManagedUnit(unitName) {
+		return nil
+	}
+	if _, err := os.Stat(s.paths.Unit(unitName)); os.IsNotExist(err) {
+		return nil
+	}
+	logger.Warn(ctx, "Refusing to overwrite unmanaged unit", "unit", unitName)
+	return fmt.Errorf("%w: %s already exists, adopt it first", ErrUnmanaged, unitName)
+}
+
+// authorize 在调用 systemd 前按策略检查操作，拒绝时记录审计日志并发布审计事件
+func (s *service) authorize(ctx context.Context, unitName string, op policy.Operation) error {
+	err := s.policy.Check(unitName, op)
+	if err == nil {
+		return nil
+	}
+
+	logger.Warn(ctx, "Operation denied by policy", "audit", true, "unit", unitName, "operation", op, "reason", err.Error())
+	s.events.Publish(events.EventAudit, strings.TrimSuffix(unitName, ".service"), map[string]interface{}{
+		"unit":      unitName,
+		"operation": string(op),
+		"decision":  "denied",
+		"reason":    err.Error(),
+	})
+	return err
+}
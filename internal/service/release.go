@@ -0,0 +1,150 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/events"
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"api-systemd/internal/pkg/workspace"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RollbackResult 回滚结果
+type RollbackResult struct {
+	Service  string         `json:"service"`
+	Release  string         `json:"release"`  // 切换后的版本
+	Previous string         `json:"previous"` // 切换前的版本
+	Jobs     []*systemd.Job `json:"jobs,omitempty"`
+}
+
+// ListReleases 列出服务已部署的版本，按部署时间从旧到新排序
+func (s *service) ListReleases(ctx context.Context, serviceName string) ([]workspace.Release, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "ListReleases validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	releases, err := s.workspaceMgr.ListReleases(serviceName)
+	if err != nil {
+		logger.Error(ctx, "Failed to list releases", "error", err, "service", serviceName)
+		return nil, err
+	}
+	return releases, nil
+}
+
+// Rollback 将 current 链接切换到指定版本并重启服务，releaseID 为空时回滚到当前版本之前的版本
+// 定时任务不重启，下次触发时使用切换后的版本
+func (s *service) Rollback(ctx context.Context, serviceName, releaseID string, opts JobOptions) (*RollbackResult, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Rollback validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	template := s.IsTemplate(serviceName)
+	unitName := systemd.UnitName(serviceName)
+	if template {
+		unitName = templateUnit(serviceName)
+	}
+	if err := s.checkUnitOperation(ctx, unitName, policy.OpRollback); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, err := s.workspaceMgr.CurrentRelease(serviceName)
+	if err != nil {
+		logger.Error(ctx, "Failed to read current release", "error", err, "service", serviceName)
+		return nil, err
+	}
+
+	var target *workspace.Release
+	if releaseID == "" {
+		target, err = s.workspaceMgr.PreviousRelease(serviceName)
+	} else {
+		target, err = s.workspaceMgr.GetRelease(serviceName, releaseID)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to find rollback release", "error", err, "service", serviceName, "release", releaseID)
+		return nil, err
+	}
+
+	logger.Info(ctx, "Rolling back service", "service", serviceName, "from", previous, "to", target.ID)
+
+	if err := s.workspaceMgr.ActivateRelease(serviceName, target.ID); err != nil {
+		logger.Error(ctx, "Failed to activate release", "error", err, "service", serviceName, "release", target.ID)
+		return nil, err
+	}
+
+	result := &RollbackResult{Service: serviceName, Release: target.ID, Previous: previous}
+	restart, err := s.rollbackUnits(ctx, serviceName, template)
+	if err != nil {
+		logger.Error(ctx, "Failed to list units to restart", "error", err, "service", serviceName)
+		s.revertRollback(ctx, serviceName, previous)
+		return result, err
+	}
+	for _, unit := range restart {
+		job, err := s.runJob(ctx, unit, "restart", opts)
+		if err != nil {
+			logger.Error(ctx, "Failed to restart service after rollback", "error", err, "service", unit)
+			s.revertRollback(ctx, serviceName, previous)
+			return result, fmt.Errorf("failed to restart service %s: %w", unit, err)
+		}
+		result.Jobs = append(result.Jobs, job)
+	}
+
+	s.events.Publish(events.EventRollback, serviceName, map[string]interface{}{
+		"release":  target.ID,
+		"previous": previous,
+	})
+
+	logger.Info(ctx, "Service rolled back successfully", "service", serviceName, "release", target.ID, "restarted", len(restart))
+	return result, nil
+}
+
+// revertRollback 重启失败时将 current 切回回滚前的版本
+func (s *service) revertRollback(ctx context.Context, serviceName, previous string) {
+	if err := s.restoreCurrent(serviceName, previous); err != nil {
+		logger.Warn(ctx, "Failed to restore previous release", "error", err, "service", serviceName, "release", previous)
+		return
+	}
+	logger.Info(ctx, "Restored previous release", "service", serviceName, "release", previous)
+}
+
+// restoreCurrent 将 current 切回指定版本，版本为空时删除 current 链接
+func (s *service) restoreCurrent(serviceName, release string) error {
+	if release == "" {
+		return s.workspaceMgr.DeactivateRelease(serviceName)
+	}
+	return s.workspaceMgr.ActivateRelease(serviceName, release)
+}
+
+// rollbackUnits 返回切换版本后需要重启的服务或实例
+// 模板服务重启已加载的实例；套接字激活的服务未运行时由下一个连接启动，不需要重启
+func (s *service) rollbackUnits(ctx context.Context, serviceName string, template bool) ([]string, error) {
+	if s.isManagedTimer(serviceName) {
+		return nil, nil
+	}
+
+	if template {
+		instances, err := s.listInstances(ctx, serviceName)
+		if err != nil {
+			return nil, err
+		}
+		units := make([]string, 0, len(instances))
+		for _, unit := range instances {
+			units = append(units, strings.TrimSuffix(unit.Name, ".service"))
+		}
+		return units, nil
+	}
+
+	if s.HasSocket(serviceName) {
+		unit, err := s.systemdMgr.Load(ctx, serviceName)
+		if err != nil || unit.ActiveState != "active" {
+			return nil, nil
+		}
+	}
+	return []string{serviceName}, nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidResources 资源调整请求不合法
+	ErrInvalidResources = errors.New("invalid resource request")
+	// ErrNoResourceChanges 资源调整请求未包含任何属性
+	ErrNoResourceChanges = errors.New("no resource properties to update")
+)
+
+// ResourceRequest 资源限制调整请求，取值采用 systemd 配置语法
+type ResourceRequest struct {
+	MemoryMax     string  `json:"memory_max,omitempty"`      // 如: "512M"、"infinity"
+	MemoryHigh    string  `json:"memory_high,omitempty"`     // 如: "384M"、"infinity"
+	MemorySwapMax string  `json:"memory_swap_max,omitempty"` // 如: "0"、"infinity"
+	CPUQuota      string  `json:"cpu_quota,omitempty"`       // 如: "50%"、"infinity"
+	CPUWeight     *uint64 `json:"cpu_weight,omitempty"`      // 1-10000
+	IOWeight      *uint64 `json:"io_weight,omitempty"`       // 1-10000
+	TasksMax      string  `json:"tasks_max,omitempty"`       // 如: "512"、"infinity"
+	Persistent    bool    `json:"persistent"`                // 是否在重启后保留
+}
+
+// UpdateResources 通过 SetUnitProperties 在线调整服务资源限制，无需重新部署或重启
+func (s *service) UpdateResources(ctx context.Context, serviceName string, req *ResourceRequest) (*systemd.Unit, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "UpdateResources validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpResources); err != nil {
+		return nil, err
+	}
+
+	properties, err := resourceProperties(req)
+	if err != nil {
+		logger.Error(ctx, "UpdateResources validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResources, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logger.Info(ctx, "Updating service resources", "service", serviceName, "persistent", req.Persistent, "properties", len(properties))
+
+	// 持久模式下 systemd 将属性写入单元目录对应 .control 目录下的 drop-in
+	if err := s.systemdMgr.SetUnitProperties(ctx, serviceName, !req.Persistent, properties); err != nil {
+		logger.Error(ctx, "Failed to set unit properties", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to update resources: %w", err)
+	}
+
+	// 同步更新记录的部署配置
+	if req.Persistent {
+		s.recordResources(ctx, serviceName, req)
+	}
+
+	unit, err := s.systemdMgr.Load(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "Failed to get service status", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to get service status: %w", err)
+	}
+
+	logger.Info(ctx, "Service resources updated", "service", serviceName, "persistent", req.Persistent)
+	return unit, nil
+}
+
+// recordResources 将持久化的资源限制写回记录的部署配置
+func (s *service) recordResources(ctx context.Context, serviceName string, req *ResourceRequest) {
+	config, err := s.workspaceMgr.LoadServiceConfig(serviceName)
+	if err != nil {
+		logger.Warn(ctx, "Failed to load service config", "error", err, "service", serviceName)
+		return
+	}
+
+	if req.MemoryMax != "" {
+		config.MemoryLimit = req.MemoryMax
+	}
+	if req.CPUQuota != "" {
+		// 单元文件中 CPUQuota 留空即不限制
+		config.CPUQuota = strings.TrimPrefix(req.CPUQuota, "infinity")
+	}
+	if req.TasksMax != "" {
+		// ServiceConfig 中 0 表示不限制
+		tasksMax, _ := strconv.Atoi(req.TasksMax)
+		config.TasksMax = tasksMax
+	}
+
+	if err := s.workspaceMgr.SaveServiceConfig(serviceName, config); err != nil {
+		logger.Warn(ctx, "Failed to save service config", "error", err, "service", serviceName)
+	}
+}
+
+// resourceProperties 将资源调整请求转换为 systemd 单元属性
+func resourceProperties(req *ResourceRequest) ([]systemd.Property, error) {
+	var properties []systemd.Property
+
+	for _, limit := range []struct {
+		name  string
+		value string
+	}{
+		{"MemoryMax", req.MemoryMax},
+		{"MemoryHigh", req.MemoryHigh},
+		{"MemorySwapMax", req.MemorySwapMax},
+	} {
+		if limit.value == "" {
+			continue
+		}
+		bytes, err := parseBytes(limit.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", limit.name, err)
+		}
+		properties = append(properties, systemd.PropUint64(limit.name, bytes))
+	}
+
+	if req.CPUQuota != "" {
+		quota, err := parseCPUQuota(req.CPUQuota)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPUQuota: %w", err)
+		}
+		properties = append(properties, systemd.PropUint64("CPUQuotaPerSecUSec", quota))
+	}
+
+	for _, weight := range []struct {
+		name  string
+		value *uint64
+	}{
+		{"CPUWeight", req.CPUWeight},
+		{"IOWeight", req.IOWeight},
+	} {
+		if weight.value == nil {
+			continue
+		}
+		if *weight.value < 1 || *weight.value > 10000 {
+			return nil, fmt.Errorf("invalid %s: must be between 1 and 10000", weight.name)
+		}
+		properties = append(properties, systemd.PropUint64(weight.name, *weight.value))
+	}
+
+	if req.TasksMax != "" {
+		tasks, err := parseLimit(req.TasksMax)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TasksMax: %w", err)
+		}
+		properties = append(properties, systemd.PropUint64("TasksMax", tasks))
+	}
+
+	if len(properties) == 0 {
+		return nil, ErrNoResourceChanges
+	}
+	return properties, nil
+}
+
+// parseLimit 解析数值或 infinity
+func parseLimit(value string) (uint64, error) {
+	if value == "infinity" {
+		return math.MaxUint64, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number or infinity", value)
+	}
+	return n, nil
+}
+
+// parseBytes 解析带 K、M、G、T 后缀（1024 进制）的字节数或 infinity
+func parseBytes(value string) (uint64, error) {
+	multiplier := uint64(1)
+	number := value
+	if n := len(value); n > 0 {
+		switch strings.ToUpper(value[n-1:]) {
+		case "K":
+			multiplier = 1 << 10
+		case "M":
+			multiplier = 1 << 20
+		case "G":
+			multiplier = 1 << 30
+		case "T":
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			number = value[:n-1]
+		}
+	}
+
+	n, err := parseLimit(number)
+	if err != nil {
+		return 0, err
+	}
+	if n == math.MaxUint64 {
+		return n, nil
+	}
+	if n > math.MaxUint64/multiplier {
+		return 0, fmt.Errorf("%q is out of range", value)
+	}
+	return n * multiplier, nil
+}
+
+// parseCPUQuota 将百分比配额转换为每秒可用的 CPU 时间（微秒）
+func parseCPUQuota(value string) (uint64, error) {
+	if value == "infinity" {
+		return math.MaxUint64, nil
+	}
+	percent, ok := strings.CutSuffix(value, "%")
+	if !ok {
+		return 0, fmt.Errorf("%q must be a percentage such as 50%%", value)
+	}
+	n, err := strconv.ParseUint(percent, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("%q must be a positive percentage", value)
+	}
+	// 100% 对应每秒 1,000,000 微秒
+	return n * 10000, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/logs"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"api-systemd/internal/pkg/workspace"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultRunTimeout 一次性命令默认的最长运行时间
+const DefaultRunTimeout = 30 * time.Minute
+
+// runLogFlushDelay 命令结束后等待 journal 输出剩余日志的时间
+const runLogFlushDelay = time.Second
+
+// ErrEmptyCommand 未指定要运行的命令
+var ErrEmptyCommand = errors.New("command cannot be empty")
+
+// RunRequest 一次性命令运行请求
+type RunRequest struct {
+	Command     []string          `json:"command"`               // 命令及参数，相对路径基于服务工作目录
+	Environment map[string]string `json:"environment,omitempty"` // 追加或覆盖的环境变量
+	Timeout     time.Duration     `json:"-"`                     // 最长运行时间，为0时使用 DefaultRunTimeout
+}
+
+// RunResult 一次性命令运行结果
+type RunResult struct {
+	Unit       string    `json:"unit"`            // 临时单元名称
+	Result     string    `json:"result"`          // systemd 服务结果: success, exit-code, signal, timeout 等
+	ExitCode   int32     `json:"exit_code"`       // 进程退出码，被信号终止时为信号值
+	Signaled   bool      `json:"signaled"`        // 是否被信号终止
+	StartedAt  time.Time `json:"started_at"`      // 开始时间
+	FinishedAt time.Time `json:"finished_at"`     // 结束时间
+	Job        string    `json:"job"`             // systemd 任务ID
+	JobResult  string    `json:"job_result"`      // systemd 任务结果
+	Error      string    `json:"error,omitempty"` // 日志跟踪等非致命错误
+}
+
+// Run 以临时单元运行一次性命令，沿用服务的用户、工作目录和环境变量
+// output 会按顺序收到命令输出的每条日志，返回时命令已结束
+func (s *service) Run(ctx context.Context, serviceName string, req *RunRequest, output func(logs.LogEntry)) (*RunResult, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Run validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if len(req.Command) == 0 || req.Command[0] == "" {
+		return nil, fmt.Errorf("validation failed: %w", ErrEmptyCommand)
+	}
+	if err := s.authorize(ctx, systemd.UnitName(serviceName), policy.OpRun); err != nil {
+		return nil, err
+	}
+
+	if !s.isManagedService(serviceName) {
+		return nil, &systemd.UnitNotFoundError{Unit: systemd.UnitName(serviceName)}
+	}
+
+	config, err := s.workspaceMgr.LoadServiceConfig(serviceName)
+	if errors.Is(err, workspace.ErrConfigNotFound) {
+		return nil, fmt.Errorf("service %s has no recorded config, redeploy it first: %w", serviceName, err)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to load service config", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to load service config: %w", err)
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRunTimeout
+	}
+
+	argv := append([]string(nil), req.Command...)
+	if !filepath.IsAbs(argv[0]) {
+		argv[0] = filepath.Join(config.WorkingDirectory, argv[0])
+	}
+
+	// 合并服务环境变量和本次运行的环境变量
+	env := make(map[string]string, len(config.Environment)+len(req.Environment))
+	for k, v := range config.Environment {
+		env[k] = v
+	}
+	for k, v := range req.Environment {
+		env[k] = v
+	}
+	environment := make([]string, 0, len(env))
+	for k, v := range env {
+		environment = append(environment, k+"="+v)
+	}
+	sort.Strings(environment)
+
+	unitName := fmt.Sprintf("%s-run-%d.service", serviceName, time.Now().UnixNano())
+
+	// oneshot 的启动任务在命令退出后才完成；RemainAfterExit 保留单元以便读取退出状态
+	properties := []systemd.Property{
+		systemd.PropString("Description", fmt.Sprintf("One-off run of %s: %s", serviceName, strings.Join(req.Command, " "))),
+		systemd.PropExecStart(argv),
+		systemd.PropString("Type", "oneshot"),
+		systemd.PropBool("RemainAfterExit", true),
+		systemd.PropDuration("TimeoutStartUSec", timeout),
+		systemd.PropStrings("Environment", environment),
+	}
+	if config.WorkingDirectory != "" {
+		properties = append(properties, systemd.PropString("WorkingDirectory", config.WorkingDirectory))
+	}
+	if config.User != "" {
+		properties = append(properties, systemd.PropString("User", config.User))
+	}
+	if config.Group != "" {
+		properties = append(properties, systemd.PropString("Group", config.Group))
+	}
+	if len(config.EnvironmentFile) > 0 {
+		properties = append(properties, systemd.PropEnvironmentFiles(config.EnvironmentFile))
+	}
+
+	// 与服务单元使用相同的加固选项，一次性命令不比服务本身受到更少的限制
+	hardening, err := transientHardening(hardeningOptions(config.Hardening, []string{config.WorkingDirectory, config.Environment["LOG_DIR"]}))
+	if err != nil {
+		logger.Error(ctx, "Failed to convert hardening options", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to convert hardening options: %w", err)
+	}
+	properties = append(properties, hardening...)
+
+	logger.Info(ctx, "Running one-off command", "service", serviceName, "unit", unitName, "command", argv)
+
+	// 单元名唯一，跟踪其全部日志即为本次运行的输出
+	logCtx, stopLogs := context.WithCancel(ctx)
+	entries, logErrs := logs.GetServiceLogsFollow(logCtx, unitName, -1, s.systemdMgr.UserMode())
+	var logErr error
+	logsDone := make(chan struct{})
+	go func() {
+		defer close(logsDone)
+		for entry := range entries {
+			if output != nil {
+				output(entry)
+			}
+		}
+		logErr = <-logErrs
+	}()
+	// 返回前停止跟踪，保证返回后不再调用 output
+	defer func() {
+		stopLogs()
+		<-logsDone
+	}()
+
+	result := &RunResult{
+		Unit:      unitName,
+		StartedAt: time.Now(),
+	}
+
+	job, err := s.systemdMgr.StartTransientUnit(ctx, unitName, properties)
+	if err != nil {
+		logger.Error(ctx, "Failed to start transient unit", "error", err, "unit", unitName)
+		return nil, fmt.Errorf("failed to run command: %w", err)
+	}
+	result.Job = job.ID
+
+	// 额外留出时间，让 systemd 先按 TimeoutStartUSec 终止命令
+	job, err = s.systemdMgr.WaitJob(ctx, job.ID, timeout+DefaultJobTimeout)
+	if err != nil {
+		// 请求被取消时终止命令，避免遗留进程
+		s.cleanupRun(context.Background(), unitName)
+		logger.Error(ctx, "Failed to wait for one-off command", "error", err, "unit", unitName)
+		return nil, fmt.Errorf("failed to wait for command: %w", err)
+	}
+	result.FinishedAt = time.Now()
+	result.JobResult = string(job.Result)
+
+	unit, err := s.systemdMgr.Load(ctx, unitName)
+	if err != nil {
+		s.cleanupRun(context.Background(), unitName)
+		logger.Error(ctx, "Failed to load transient unit", "error", err, "unit", unitName)
+		return nil, fmt.Errorf("failed to get command status: %w", err)
+	}
+	result.Result = unit.Result
+	result.ExitCode = unit.ExecMainStatus
+	result.Signaled = unit.ExecMainCode == 2 || unit.ExecMainCode == 3
+
+	s.cleanupRun(ctx, unitName)
+
+	// 等待 journal 输出剩余日志后停止跟踪
+	time.Sleep(runLogFlushDelay)
+	stopLogs()
+	<-logsDone
+	if logErr != nil {
+		logger.Warn(ctx, "Failed to follow command output", "error", logErr, "unit", unitName)
+		result.Error = fmt.Sprintf("failed to follow command output: %v", logErr)
+	}
+
+	logger.Info(ctx, "One-off command finished", "service", serviceName, "unit", unitName, "result", result.Result, "exit_code", result.ExitCode)
+	return result, nil
+}
+
+// cleanupRun 停止临时单元并清除失败状态，使 systemd 回收该单元
+func (s *service) cleanupRun(ctx context.Context, unitName string) {
+	if _, err := s.systemdMgr.SendAndWait(ctx, unitName, "stop", "replace", DefaultJobTimeout); err != nil {
+		logger.Warn(ctx, "Failed to stop transient unit", "error", err, "unit", unitName)
+	}
+	if err := s.systemdMgr.ResetFailedUnit(ctx, unitName); err != nil && !systemd.IsUnitNotFound(err) {
+		logger.Warn(ctx, "Failed to reset transient unit", "error", err, "unit", unitName)
+	}
+}
+
+// transientHardening 将渲染的加固配置项转换为临时单元属性
+func transientHardening(options []UnitOption) ([]systemd.Property, error) {
+	var properties []systemd.Property
+	var rwPaths []string
+	for _, opt := range options {
+		words := strings.Fields(opt.Value)
+		switch opt.Name {
+		case "DynamicUser", "PrivateTmp", "NoNewPrivileges", "PrivateDevices":
+			properties = append(properties, systemd.PropBool(opt.Name, opt.Value == "yes"))
+		case "ProtectSystem", "ProtectHome":
+			properties = append(properties, systemd.PropString(opt.Name, opt.Value))
+		case "RestrictAddressFamilies", "SystemCallFilter":
+			allowList := true
+			if len(words) > 0 && strings.HasPrefix(words[0], "~") {
+				allowList = false
+				words[0] = strings.TrimPrefix(words[0], "~")
+			}
+			if opt.Name == "RestrictAddressFamilies" && len(words) == 1 && words[0] == "none" {
+				words = []string{}
+			}
+			properties = append(properties, systemd.PropFilter(opt.Name, allowList, words))
+		case "CapabilityBoundingSet":
+			property, err := systemd.PropCapabilities(opt.Name, words)
+			if err != nil {
+				return nil, err
+			}
+			properties = append(properties, property)
+		case "ReadWritePaths":
+			rwPaths = append(rwPaths, opt.Value)
+		default:
+			return nil, fmt.Errorf("unsupported hardening option %s", opt.Name)
+		}
+	}
+	if len(rwPaths) > 0 {
+		properties = append(properties, systemd.PropStrings("ReadWritePaths", rwPaths))
+	}
+	return properties, nil
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/validator"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SecurityCheck 单项安全检查结果
+type SecurityCheck struct {
+	Name        string  `json:"name"`            // 检查的配置项
+	Description string  `json:"description"`     // 检查内容
+	Value       string  `json:"value,omitempty"` // 单元中的实际取值，未设置时为空
+	Passed      bool    `json:"passed"`          // 是否完全满足
+	Exposure    float64 `json:"exposure"`        // 该项贡献的暴露度
+}
+
+// SecurityReport 单元的安全暴露度评估，类似 systemd-analyze security
+type SecurityReport struct {
+	Unit     string          `json:"unit"`
+	Exposure float64         `json:"exposure"` // 0.0（完全隔离）到 10.0（无任何限制）
+	Rating   string          `json:"rating"`   // PERFECT, SAFE, OK, MEDIUM, EXPOSED, UNSAFE
+	Checks   []SecurityCheck `json:"checks"`
+}
+
+// securityCheck 安全检查项，badness 返回 0（安全）到 1（完全暴露）
+type securityCheck struct {
+	name        string
+	description string
+	weight      float64
+	badness     func(value string, set bool, opts securityOptions) float64
+}
+
+// securityOptions 单元中与安全相关的最终取值
+type securityOptions map[string]string
+
+// get 返回配置项的取值及是否设置
+func (o securityOptions) get(name string) (string, bool) {
+	value, ok := o[name]
+	return value, ok
+}
+
+// enabled 判断布尔配置项是否开启
+func (o securityOptions) enabled(name string) bool {
+	var b bool
+	value, ok := o[name]
+	return ok && parseUnitBool(value, &b) && b
+}
+
+// securityChecks 参与评分的检查项，权重越高对暴露度的影响越大
+var securityChecks = []securityCheck{
+	{"User", "Service runs as a non-root user", 2000, func(value string, set bool, opts securityOptions) float64 {
+		if opts.enabled("DynamicUser") || (set && value != "" && value != "root" && value != "0") {
+			return 0
+		}
+		return 1
+	}},
+	{"NoNewPrivileges", "Service processes cannot acquire new privileges", 1000, boolBadness},
+	{"ProtectSystem", "Service has read-only access to the OS file hierarchy", 1000, func(value string, set bool, _ securityOptions) float64 {
+		switch value {
+		case "strict":
+			return 0
+		case "full":
+			return 0.3
+		case "yes", "true", "1":
+			return 0.6
+		}
+		return 1
+	}},
+	{"ProtectHome", "Service has no or read-only access to home directories", 1000, func(value string, set bool, _ securityOptions) float64 {
+		switch value {
+		case "yes", "true", "1", "tmpfs":
+			return 0
+		case "read-only":
+			return 0.5
+		}
+		return 1
+	}},
+	{"PrivateTmp", "Service has a private /tmp", 1000, boolBadness},
+	{"PrivateDevices", "Service has no access to hardware devices", 1000, boolBadness},
+	{"CapabilityBoundingSet", "Service has a restricted capability bounding set", 1500, func(value string, set bool, _ securityOptions) float64 {
+		switch {
+		case !set:
+			return 1
+		case value == "":
+			return 0
+		case strings.HasPrefix(value, "~"):
+			return 0.6
+		}
+		return 0.3
+	}},
+	{"RestrictAddressFamilies", "Service is restricted to a set of socket address families", 1000, func(value string, set bool, _ securityOptions) float64 {
+		switch {
+		case !set || value == "" || strings.HasPrefix(value, "~"):
+			return 1
+		case value == "none":
+			return 0
+		case strings.Contains(value, "AF_PACKET") || strings.Contains(value, "AF_NETLINK"):
+			return 0.5
+		}
+		return 0.2
+	}},
+	{"SystemCallFilter", "Service is restricted to a set of system calls", 1000, func(value string, set bool, _ securityOptions) float64 {
+		switch {
+		case !set || value == "":
+			return 1
+		case strings.HasPrefix(value, "~"):
+			return 0.5
+		}
+		return 0
+	}},
+	{"ProtectKernelTunables", "Service cannot alter kernel tunables", 500, boolBadness},
+	{"ProtectKernelModules", "Service cannot load or unload kernel modules", 500, boolBadness},
+	{"ProtectControlGroups", "Service cannot modify the control group hierarchy", 500, boolBadness},
+	{"RestrictNamespaces", "Service cannot create namespaces", 500, boolBadness},
+	{"RestrictSUIDSGID", "Service cannot create SUID/SGID files", 500, boolBadness},
+	{"LockPersonality", "Service cannot change the ABI personality", 300, boolBadness},
+	{"MemoryDenyWriteExecute", "Service cannot create writable executable memory", 300, boolBadness},
+	{"RestrictRealtime", "Service cannot acquire realtime scheduling", 300, boolBadness},
+}
+
+// boolBadness 布尔配置项开启时为 0，否则为 1
+func boolBadness(value string, set bool, _ securityOptions) float64 {
+	var b bool
+	if set && parseUnitBool(value, &b) && b {
+		return 0
+	}
+	return 1
+}
+
+// securityRatings 暴露度评级，按上限升序排列
+var securityRatings = []struct {
+	max    float64
+	rating string
+}{
+	{1, "PERFECT"},
+	{2, "SAFE"},
+	{4, "OK"},
+	{7, "MEDIUM"},
+	{9, "EXPOSED"},
+	{math.Inf(1), "UNSAFE"},
+}
+
+// dynamicUserImplies DynamicUser=yes 隐含开启的选项
+var dynamicUserImplies = map[string]string{
+	"ProtectSystem":    "strict",
+	"ProtectHome":      "read-only",
+	"PrivateTmp":       "yes",
+	"NoNewPrivileges":  "yes",
+	"RestrictSUIDSGID": "yes",
+}
+
+// listOptions 可以多次赋值并累加的配置项
+var listOptions = map[string]bool{
+	"CapabilityBoundingSet":   true,
+	"RestrictAddressFamilies": true,
+	"SystemCallFilter":        true,
+}
+
+// ScoreSecurity 根据单元配置项计算安全暴露度
+func ScoreSecurity(unitName string, options []UnitOption) *SecurityReport {
+	opts := securityOptions{}
+	for _, opt := range options {
+		if opt.Section != "Service" {
+			continue
+		}
+		// 列表配置项累加，空赋值重置；其余配置项以最后一次赋值为准
+		if prev, ok := opts[opt.Name]; ok && listOptions[opt.Name] && prev != "" && opt.Value != "" {
+			opts[opt.Name] = prev + " " + opt.Value
+			continue
+		}
+		opts[opt.Name] = opt.Value
+	}
+	if opts.enabled("DynamicUser") {
+		for name, value := range dynamicUserImplies {
+			if _, ok := opts[name]; !ok {
+				opts[name] = value
+			}
+		}
+	}
+
+	var total float64
+	for _, check := range securityChecks {
+		total += check.weight
+	}
+
+	report := &SecurityReport{Unit: unitName, Checks: make([]SecurityCheck, 0, len(securityChecks))}
+	for _, check := range securityChecks {
+		value, set := opts.get(check.name)
+		badness := check.badness(value, set, opts)
+		exposure := math.Round(badness*check.weight/total*100) / 10
+		report.Exposure += badness * check.weight / total * 10
+		report.Checks = append(report.Checks, SecurityCheck{
+			Name:        check.name,
+			Description: check.description,
+			Value:       value,
+			Passed:      badness == 0,
+			Exposure:    exposure,
+		})
+	}
+	report.Exposure = math.Round(report.Exposure*10) / 10
+
+	for _, r := range securityRatings {
+		if report.Exposure < r.max {
+			report.Rating = r.rating
+			break
+		}
+	}
+	return report
+}
+
+// GetSecurity 根据单元文件及其 drop-in 评估服务的安全暴露度
+func (s *service) GetSecurity(ctx context.Context, serviceName string) (*SecurityReport, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetSecurity validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	unitName, _, options, err := s.loadUnitOptions(ctx, serviceName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return ScoreSecurity(unitName, options), nil
+}
@@ -2,20 +2,26 @@ package service
 
 import (
 	"api-systemd/internal/pkg/artifact"
+	"api-systemd/internal/pkg/events"
 	"api-systemd/internal/pkg/hooks"
 	"api-systemd/internal/pkg/logger"
 	"api-systemd/internal/pkg/logs"
+	"api-systemd/internal/pkg/policy"
 	"api-systemd/internal/pkg/systemd"
 	"api-systemd/internal/pkg/telemetry"
+	"api-systemd/internal/pkg/unitpath"
+	"api-systemd/internal/pkg/unitstore"
 	"api-systemd/internal/pkg/validator"
 	"api-systemd/internal/pkg/workspace"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -26,11 +32,13 @@ type Service interface {
 	// Deploy 部署服务
 	Deploy(ctx context.Context, params *DeployRequest) error
 	// Start 启动服务
-	Start(ctx context.Context, serviceName string) error
+	Start(ctx context.Context, serviceName string, opts JobOptions) (*systemd.Job, error)
 	// Stop 停止服务
-	Stop(ctx context.Context, serviceName string) error
+	Stop(ctx context.Context, serviceName string, opts JobOptions) (*systemd.Job, error)
 	// Restart 重启服务
-	Restart(ctx context.Context, serviceName string) error
+	Restart(ctx context.Context, serviceName string, opts JobOptions) (*systemd.Job, error)
+	// Reload 重新加载服务配置
+	Reload(ctx context.Context, serviceName, mode string, opts JobOptions) (*systemd.Job, error)
 	// Remove 移除服务
 	Remove(ctx context.Context, serviceName string) error
 	// GetStatus 获取服务状态
@@ -39,6 +47,103 @@ type Service interface {
 	GetLogs(ctx context.Context, serviceName string, lines int) ([]logs.LogEntry, error)
 	// ListServices 获取服务列表
 	ListServices(ctx context.Context) ([]ServiceInfo, error)
+	// GetJob 获取 systemd 任务状态
+	GetJob(ctx context.Context, jobID string) (*systemd.Job, error)
+	// Kill 向服务进程发送信号
+	Kill(ctx context.Context, serviceName, signal, target string) error
+	// ResetFailed 清除服务失败状态
+	ResetFailed(ctx context.Context, serviceName string) error
+	// Mask 屏蔽服务
+	Mask(ctx context.Context, serviceName string) error
+	// Unmask 取消服务屏蔽
+	Unmask(ctx context.Context, serviceName string) error
+	// UpdateResources 在线调整服务资源限制
+	UpdateResources(ctx context.Context, serviceName string, req *ResourceRequest) (*systemd.Unit, error)
+	// ListDropIns 列出单元的 drop-in
+	ListDropIns(ctx context.Context, serviceName string) ([]DropIn, error)
+	// GetDropIn 读取 drop-in
+	GetDropIn(ctx context.Context, serviceName, name string) (*DropIn, error)
+	// CreateDropIn 创建 drop-in
+	CreateDropIn(ctx context.Context, serviceName, name, content string) (*DropIn, error)
+	// UpdateDropIn 更新 drop-in
+	UpdateDropIn(ctx context.Context, serviceName, name, content string) (*DropIn, error)
+	// DeleteDropIn 删除 drop-in
+	DeleteDropIn(ctx context.Context, serviceName, name string) error
+	// GetEffectiveUnit 获取合并 drop-in 后的单元视图
+	GetEffectiveUnit(ctx context.Context, serviceName string) (*EffectiveUnit, error)
+	// Adopt 接管已有的单元
+	Adopt(ctx context.Context, serviceName string, req *AdoptRequest) (*AdoptResult, error)
+	// GetServiceConfig 从单元文件解析服务配置
+	GetServiceConfig(ctx context.Context, serviceName string) (*UnitConfig, error)
+	// GetDependencies 获取服务的依赖图
+	GetDependencies(ctx context.Context, serviceName string, depth int) (*systemd.DependencyGraph, error)
+	// GetSecurity 评估服务的安全暴露度
+	GetSecurity(ctx context.Context, serviceName string) (*SecurityReport, error)
+
+	// HasSocket 判断服务是否部署了配对的套接字
+	HasSocket(serviceName string) bool
+	// GetSocketStatus 获取套接字激活服务及其套接字的状态
+	GetSocketStatus(ctx context.Context, serviceName string) (*SocketStatus, error)
+
+	// IsTemplate 判断服务是否以模板单元部署
+	IsTemplate(serviceName string) bool
+	// Scale 调整模板服务的实例数
+	Scale(ctx context.Context, serviceName string, instances int, opts JobOptions) (*ScaleResult, error)
+	// GetTemplateStatus 获取模板服务所有实例的汇总状态
+	GetTemplateStatus(ctx context.Context, serviceName string) (*TemplateStatus, error)
+
+	// ListReleases 列出服务已部署的版本
+	ListReleases(ctx context.Context, serviceName string) ([]workspace.Release, error)
+	// Rollback 切换到之前的版本并重启服务
+	Rollback(ctx context.Context, serviceName, releaseID string, opts JobOptions) (*RollbackResult, error)
+
+	// Run 以临时单元运行一次性命令
+	Run(ctx context.Context, serviceName string, req *RunRequest, output func(logs.LogEntry)) (*RunResult, error)
+
+	// DeployTimer 部署定时任务
+	DeployTimer(ctx context.Context, params *DeployTimerRequest) error
+	// ListTimers 获取定时任务列表
+	ListTimers(ctx context.Context) ([]*systemd.Timer, error)
+	// GetTimer 获取定时任务状态
+	GetTimer(ctx context.Context, serviceName string) (*systemd.Timer, error)
+	// TriggerTimer 立即运行一次定时任务
+	TriggerTimer(ctx context.Context, serviceName string, opts JobOptions) (*systemd.Job, error)
+	// RemoveTimer 移除定时任务
+	RemoveTimer(ctx context.Context, serviceName string) error
+
+	// WriteUnitFile 写入原始单元文件
+	WriteUnitFile(ctx context.Context, serviceName, content string) (string, error)
+	// RemoveUnitFile 删除单元文件
+	RemoveUnitFile(ctx context.Context, serviceName string) (string, error)
+	// ListUnitVersions 列出单元文件的历史版本
+	ListUnitVersions(ctx context.Context, serviceName, file string) ([]unitstore.Version, error)
+	// GetUnitVersion 读取单元文件的历史版本
+	GetUnitVersion(ctx context.Context, serviceName, file string, id int) (*UnitVersion, error)
+	// DiffUnitVersions 比较单元文件的历史版本
+	DiffUnitVersions(ctx context.Context, serviceName, file string, from, to int) (string, error)
+	// RestoreUnitVersion 恢复单元文件的历史版本
+	RestoreUnitVersion(ctx context.Context, serviceName, file string, id int) error
+}
+
+// 重新加载模式
+const (
+	ReloadModeReload          = "reload"            // 仅重新加载，服务不支持时返回错误
+	ReloadModeReloadOrRestart = "reload-or-restart" // 支持时重新加载，否则重启
+)
+
+// ErrUserNotSupported 用户模式下不能指定运行用户和用户组
+var ErrUserNotSupported = errors.New("user and group cannot be set in user mode")
+
+// ErrInvalidReloadMode 无法识别的重新加载模式
+var ErrInvalidReloadMode = errors.New("invalid reload mode, must be reload or reload-or-restart")
+
+// DefaultJobTimeout 默认的任务等待时间
+const DefaultJobTimeout = 20 * time.Second
+
+// JobOptions 任务等待选项
+type JobOptions struct {
+	Wait    bool          // 是否等待任务完成
+	Timeout time.Duration // 等待超时时间，为0时使用 DefaultJobTimeout
 }
 
 type service struct {
@@ -47,22 +152,57 @@ type service struct {
 	otelReporter *telemetry.OTELReporter
 	workspaceMgr *workspace.Manager
 	artifactMgr  *artifact.Manager
+	systemdMgr   *systemd.Manager
+	events       *events.Broker
+	paths        *unitpath.Resolver // 单元文件路径解析
+	units        *unitstore.Store   // 单元文件写入器，保留历史版本
+
+	// allowUnmanaged 允许对不带所有权标记的单元执行变更操作
+	allowUnmanaged bool
+	// policy 单元操作策略，在调用 systemd 前检查
+	policy *policy.Policy
+	// releaseLimit 每个服务保留的部署版本数量
+	releaseLimit int
+	// managed 受管单元名的内存集合
+	managed managedSet
 }
 
-func NewService(workDir string) Service {
+func NewService(workDir string, systemdMgr *systemd.Manager, paths *unitpath.Resolver, broker *events.Broker, allowUnmanaged bool, pol *policy.Policy, unitHistory, releaseLimit int) Service {
 	workspaceMgr := workspace.NewManager(workDir)
 
 	// 初始化工作空间
 	if err := workspaceMgr.InitWorkspace(); err != nil {
 		// 记录错误但不阻止服务启动
-		fmt.Printf("Warning: failed to initialize workspace: %v\n", err)
+		logger.Warn(context.Background(), "Failed to initialize workspace", "error", err, "dir", workDir)
+	}
+
+	// 单元目录（如 ~/.config/systemd/user 或测试用的临时目录）可能尚未创建
+	if err := os.MkdirAll(paths.Dir(), 0755); err != nil {
+		logger.Warn(context.Background(), "Failed to create unit directory", "error", err, "dir", paths.Dir())
 	}
 
-	return &service{
+	s := &service{
 		hookExecutor: hooks.NewHookExecutor(),
 		workspaceMgr: workspaceMgr,
 		artifactMgr:  artifact.NewManager(),
+		systemdMgr:   systemdMgr,
+		events:       broker,
+		paths:        paths,
+		units:        unitstore.New(paths.Dir(), workspaceMgr.GetHistoryDir(), unitHistory),
+
+		allowUnmanaged: allowUnmanaged,
+		policy:         pol,
+		releaseLimit:   releaseLimit,
+		managed:        managedSet{units: make(map[string]bool)},
 	}
+
+	s.loadManagedUnits()
+	s.units.OnChange(s.unitFileChanged)
+
+	// 将受管服务的状态变化转发为事件
+	systemdMgr.OnUnitStateChange(s.publishUnitState)
+
+	return s
 }
 
 // DeployRequest 部署请求
@@ -74,6 +214,8 @@ type DeployRequest struct {
 	Config        *hooks.ServiceConfig      `json:"config,omitempty"`        // 服务配置
 	Hooks         []hooks.Hook              `json:"hooks,omitempty"`         // 生命周期钩子
 	Notifications *hooks.NotificationConfig `json:"notifications,omitempty"` // 通知配置
+	Template      bool                      `json:"template,omitempty"`      // 以模板单元 name@.service 部署，命令和环境变量中可使用 %i
+	Instances     int                       `json:"instances,omitempty"`     // 模板服务初始启动的实例数，默认为 1
 }
 
 // ServiceInfo 服务信息
@@ -83,33 +225,238 @@ type ServiceInfo struct {
 	Description string `json:"description"` // 服务描述
 	Path        string `json:"path"`        // 服务路径
 	Enabled     bool   `json:"enabled"`     // 是否启用
+
+	// 模板服务按模板分组，Instances 为已加载的实例
+	Template  bool          `json:"template,omitempty"`
+	Instances []ServiceInfo `json:"instances,omitempty"`
+
+	// 套接字激活服务配对的套接字
+	Socket *SocketInfo `json:"socket,omitempty"`
 }
 
 // Deploy 部署服务（统一的增强版本）
-func (s *service) Deploy(ctx context.Context, params *DeployRequest) error {
+func (s *service) Deploy(ctx context.Context, params *DeployRequest) (err error) {
 	// 参数验证
 	if err := validator.ValidateServiceName(params.Service); err != nil {
 		logger.Error(ctx, "Deploy validation failed", "error", err, "service", params.Service)
 		return fmt.Errorf("validation failed: %w", err)
 	}
+	unitName := systemd.UnitName(params.Service)
+	if params.Template {
+		if params.Instances < 0 || params.Instances > MaxInstances {
+			return fmt.Errorf("validation failed: %w", ErrInvalidInstances)
+		}
+		if params.Instances == 0 {
+			params.Instances = 1
+		}
+		unitName = templateUnit(params.Service)
+	}
+	socket := params.Config != nil && params.Config.Socket != nil
+	if socket && params.Config.Socket.Accept != params.Template {
+		return fmt.Errorf("validation failed: %w", ErrSocketTemplate)
+	}
 
 	// 并发控制
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// 不覆盖手工编写的同名单元
+	if err := s.checkDeployTarget(ctx, unitName); err != nil {
+		return err
+	}
+	if socket {
+		if err := s.checkDeployTarget(ctx, socketUnitName(params.Service)); err != nil {
+			return err
+		}
+	}
+	hadSocket := s.HasSocket(params.Service)
+
 	logger.Info(ctx, "Starting deployment", "service", params.Service, "url", params.PackageURL)
 
+	// 发布部署事件
+	s.events.Publish(events.EventDeploy, params.Service, map[string]interface{}{
+		"phase":       "started",
+		"package_url": params.PackageURL,
+	})
+	defer func() {
+		data := map[string]interface{}{
+			"phase":       "completed",
+			"package_url": params.PackageURL,
+		}
+		if err != nil {
+			data["phase"] = "failed"
+			data["error"] = err.Error()
+		}
+		s.events.Publish(events.EventDeploy, params.Service, data)
+	}()
+
+	// 准备部署：目录、钩子、产物和服务配置
+	d, err := s.prepareDeployment(ctx, params)
+	if err != nil {
+		return err
+	}
+	// 后续步骤失败时恢复部署前的单元文件和版本
+	defer func() {
+		if err != nil {
+			s.revertDeployment(ctx, params.Service, d)
+		}
+	}()
+	config := d.config
+
+	// 写入systemd配置
+	systemdFile := s.paths.Unit(unitName)
+	systemdConfig := s.newSystemdConfig(params.Service, params.StartCommand, config)
+	s.snapshotUnit(ctx, d, systemdFile)
+
+	if err := s.writeUnit(systemdFile, systemdConfig); err != nil {
+		logger.Error(ctx, "Failed to write systemd config", "error", err, "file", systemdFile)
+		return fmt.Errorf("failed to write systemd config: %w", err)
+	}
+
+	logger.Info(ctx, "Creating systemd config", "service", params.Service, "path", config.WorkingDirectory)
+
+	// 写入配对的套接字，不再配置套接字时移除上次部署的套接字
+	if socket || hadSocket {
+		s.snapshotUnit(ctx, d, s.paths.Unit(socketUnitName(params.Service)))
+	}
+	if socket {
+		description := config.Description
+		if params.Template {
+			description = fmt.Sprintf("%s Service", params.Service)
+		}
+		socketFile := s.paths.Unit(socketUnitName(params.Service))
+		if err := s.writeUnit(socketFile, NewSocketSystemdConfig(params.Service, description, config.Socket)); err != nil {
+			logger.Error(ctx, "Failed to write socket config", "error", err, "file", socketFile)
+			return fmt.Errorf("failed to write socket config: %w", err)
+		}
+		logger.Info(ctx, "Created socket config", "service", params.Service, "socket", socketFile)
+	} else if hadSocket {
+		if err := s.removeSocket(ctx, params.Service); err != nil {
+			return err
+		}
+	}
+
+	// 记录部署配置，供一次性命令等操作复用
+	if err := s.workspaceMgr.SaveServiceConfig(params.Service, config); err != nil {
+		logger.Warn(ctx, "Failed to save service config", "error", err, "service", params.Service)
+	}
+
+	// 重新加载systemd
+	logger.Info(ctx, "Reloading systemd daemon")
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+
+	// 套接字激活：由 systemd 持有监听套接字，服务在第一个连接到达时启动
+	// Accept=yes 时每个连接启动一个模板实例，不再按实例数启动
+	if socket {
+		// 之前自行监听的服务会占用地址，先停止
+		if !hadSocket && !params.Template {
+			if _, err := s.runJob(ctx, params.Service, "stop", JobOptions{Wait: true}); err != nil && !systemd.IsUnitNotFound(err) {
+				logger.Error(ctx, "Failed to stop service", "error", err, "service", params.Service)
+				return fmt.Errorf("failed to stop service: %w", err)
+			}
+		}
+		if err := s.startSocket(ctx, params.Service); err != nil {
+			return err
+		}
+		// 已由套接字启动的服务仍在运行上一个版本，重启后切换到新版本
+		if hadSocket && !params.Template {
+			if unit, err := s.systemdMgr.Load(ctx, params.Service); err == nil && unit.ActiveState == "active" {
+				if _, err := s.runJob(ctx, params.Service, "restart", JobOptions{Wait: true}); err != nil {
+					logger.Error(ctx, "Failed to restart service", "error", err, "service", params.Service)
+					return fmt.Errorf("failed to restart service: %w", err)
+				}
+			}
+		}
+		s.finishDeployment(ctx, params, d)
+
+		logger.Info(ctx, "Deployment completed successfully", "service", params.Service, "socket", socketUnitName(params.Service))
+		return nil
+	}
+
+	// 模板单元本身不能启动，按实例数启用并重启 name@1 到 name@N，已运行的实例切换到新版本
+	if params.Template {
+		if _, err := s.scaleInstances(ctx, params.Service, params.Instances, "restart", JobOptions{Wait: true}); err != nil {
+			return err
+		}
+		s.finishDeployment(ctx, params, d)
+
+		logger.Info(ctx, "Deployment completed successfully", "service", params.Service, "instances", params.Instances)
+		return nil
+	}
+
+	// 启用和启动服务
+	logger.Info(ctx, "Enabling service", "service", params.Service)
+	if err := s.systemdMgr.EnableUnit(ctx, params.Service, s.paths.Runtime()); err != nil {
+		logger.Error(ctx, "Failed to enable service", "error", err, "service", params.Service)
+		return fmt.Errorf("failed to enable service: %w", err)
+	}
+
+	// 重新部署时服务仍在运行上一个版本，restart 对未运行的服务等同于 start
+	logger.Info(ctx, "Restarting service", "service", params.Service)
+	job, err := s.runJob(ctx, params.Service, "restart", JobOptions{Wait: true})
+	if err != nil {
+		logger.Error(ctx, "Failed to start service", "error", err, "service", params.Service)
+		return fmt.Errorf("failed to start service: %w", err)
+	}
+	logger.Info(ctx, "Restart job finished", "service", params.Service, "job", job.ID, "state", job.State, "result", job.Result)
+
+	s.finishDeployment(ctx, params, d)
+
+	logger.Info(ctx, "Deployment completed successfully", "service", params.Service)
+	return nil
+}
+
+// deployment 部署过程中准备好的产物和配置
+type deployment struct {
+	config     *hooks.ServiceConfig
+	serviceDir string
+	logDir     string
+	release    string // 本次部署的版本号
+	previous   string // 部署前 current 指向的版本号，首次部署为空
+	units      []unitSnapshot
+}
+
+// unitSnapshot 部署写入或删除单元文件前的历史版本，version 为 0 表示文件原本不存在
+type unitSnapshot struct {
+	path    string
+	version int
+}
+
+// prepareDeployment 创建目录、执行 pre-start 钩子、下载产物并生成服务配置
+func (s *service) prepareDeployment(ctx context.Context, params *DeployRequest) (*deployment, error) {
+	// 用户管理器只能以当前用户运行服务
+	if s.systemdMgr.UserMode() && params.Config != nil && (params.Config.User != "" || params.Config.Group != "") {
+		logger.Error(ctx, "Deploy validation failed", "error", ErrUserNotSupported, "service", params.Service)
+		return nil, fmt.Errorf("validation failed: %w", ErrUserNotSupported)
+	}
+	if s.systemdMgr.UserMode() && params.Config != nil && params.Config.Socket != nil && params.Config.Socket.SocketUser != "" {
+		logger.Error(ctx, "Deploy validation failed", "error", ErrUserNotSupported, "service", params.Service)
+		return nil, fmt.Errorf("validation failed: %w", ErrUserNotSupported)
+	}
+	if s.systemdMgr.UserMode() && params.Config != nil && params.Config.Hardening != nil &&
+		params.Config.Hardening.DynamicUser != nil && *params.Config.Hardening.DynamicUser {
+		logger.Error(ctx, "Deploy validation failed", "error", ErrUserNotSupported, "service", params.Service)
+		return nil, fmt.Errorf("validation failed: %w", ErrUserNotSupported)
+	}
+	if err := validator.ValidateServiceConfig(params.Config); err != nil {
+		logger.Error(ctx, "Deploy validation failed", "error", err, "service", params.Service)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
 	// 创建服务和日志目录
 	serviceDir, err := s.workspaceMgr.EnsureServiceDir(params.Service)
 	if err != nil {
 		logger.Error(ctx, "Failed to create service directory", "error", err, "service", params.Service)
-		return fmt.Errorf("failed to create service directory: %w", err)
+		return nil, fmt.Errorf("failed to create service directory: %w", err)
 	}
 
 	logDir, err := s.workspaceMgr.EnsureLogDir(params.Service)
 	if err != nil {
 		logger.Error(ctx, "Failed to create log directory", "error", err, "service", params.Service)
-		return fmt.Errorf("failed to create log directory: %w", err)
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
 	logger.Info(ctx, "Created service directories",
@@ -136,12 +483,13 @@ func (s *service) Deploy(ctx context.Context, params *DeployRequest) error {
 
 		// 检查关键钩子是否失败
 		for _, event := range events {
+			s.publishHookEvent(event)
 			if event.Status == "failure" {
 				logger.Error(ctx, "Pre-start hook failed", "service", params.Service, "hook", event.HookType, "error", event.Error)
 				if s.otelReporter != nil {
 					s.otelReporter.ReportHookExecution(ctx, event)
 				}
-				return fmt.Errorf("pre-start hook failed: %s", event.Error)
+				return nil, fmt.Errorf("pre-start hook failed: %s", event.Error)
 			}
 			if s.otelReporter != nil {
 				s.otelReporter.ReportHookExecution(ctx, event)
@@ -152,39 +500,69 @@ func (s *service) Deploy(ctx context.Context, params *DeployRequest) error {
 	// 验证URL格式
 	if err := s.artifactMgr.ValidateURL(params.PackageURL); err != nil {
 		logger.Error(ctx, "Invalid package URL", "error", err, "url", params.PackageURL)
-		return fmt.Errorf("invalid package URL: %w", err)
+		return nil, fmt.Errorf("invalid package URL: %w", err)
+	}
+
+	// 下载并解压产物到暂存目录，解压失败不影响正在运行的版本
+	staging, err := s.workspaceMgr.StageRelease(params.Service)
+	if err != nil {
+		logger.Error(ctx, "Failed to create staging directory", "error", err, "service", params.Service)
+		return nil, err
 	}
+	defer os.RemoveAll(staging)
 
-	// 下载并解压产物
-	folders, err := s.artifactMgr.DownloadAndExtract(params.PackageURL, serviceDir)
+	folders, err := s.artifactMgr.DownloadAndExtract(params.PackageURL, staging)
 	if err != nil {
 		logger.Error(ctx, "Failed to download and extract artifact", "error", err, "url", params.PackageURL)
-		return fmt.Errorf("failed to download and extract artifact: %w", err)
+		return nil, fmt.Errorf("failed to download and extract artifact: %w", err)
 	}
 
 	// 获取解压后的第一个文件夹
 	folder := s.artifactMgr.GetFirstFolder(folders)
 	if len(folder) == 0 {
 		logger.Error(ctx, "No folders extracted from package")
-		return fmt.Errorf("failed to extract folder name")
+		return nil, fmt.Errorf("failed to extract folder name")
 	}
 
+	// 提交为新版本并原子切换 current 链接，单元的工作目录始终指向 current
+	previous, err := s.workspaceMgr.CurrentRelease(params.Service)
+	if err != nil {
+		logger.Error(ctx, "Failed to read current release", "error", err, "service", params.Service)
+		return nil, err
+	}
+	release, err := s.workspaceMgr.CommitRelease(params.Service, staging, folder)
+	if err != nil {
+		logger.Error(ctx, "Failed to commit release", "error", err, "service", params.Service)
+		return nil, err
+	}
+	if err := s.workspaceMgr.ActivateRelease(params.Service, release.ID); err != nil {
+		logger.Error(ctx, "Failed to activate release", "error", err, "service", params.Service, "release", release.ID)
+		s.workspaceMgr.RemoveRelease(params.Service, release.ID)
+		return nil, err
+	}
+	currentDir := s.workspaceMgr.GetCurrentDir(params.Service)
+
+	logger.Info(ctx, "Activated release", "service", params.Service, "release", release.ID, "path", release.Path)
+
 	// 创建服务配置
 	var config *hooks.ServiceConfig
 	if params.Config != nil {
 		config = params.Config
 		config.ServiceName = params.Service
-		config.WorkingDirectory = filepath.Join(serviceDir, folder)
-		config.ExecStart = filepath.Join(serviceDir, folder, params.StartCommand)
+		config.WorkingDirectory = currentDir
+		config.ExecStart = filepath.Join(currentDir, params.StartCommand)
 	} else {
 		config = &hooks.ServiceConfig{
 			ServiceName:      params.Service,
 			Description:      fmt.Sprintf("%s Service", params.Service),
-			WorkingDirectory: filepath.Join(serviceDir, folder),
-			ExecStart:        filepath.Join(serviceDir, folder, params.StartCommand),
+			WorkingDirectory: currentDir,
+			ExecStart:        filepath.Join(currentDir, params.StartCommand),
 			RestartPolicy:    "always",
 			Hooks:            []hooks.Hook{},
 		}
+		if params.Template {
+			config.Description = fmt.Sprintf("%s Service (instance %%i)", params.Service)
+		}
 	}
 
 	// 设置日志目录环境变量
@@ -198,37 +576,95 @@ func (s *service) Deploy(ctx context.Context, params *DeployRequest) error {
 		config.Hooks = append(config.Hooks, params.Hooks...)
 	}
 
-	// 写入systemd配置
-	systemdFile := fmt.Sprintf("/etc/systemd/system/%s.service", params.Service)
-	systemdConfig := NewSystemdConfig(params.Service, config.WorkingDirectory, params.StartCommand, config)
+	return &deployment{
+		config:     config,
+		serviceDir: serviceDir,
+		logDir:     logDir,
+		release:    release.ID,
+		previous:   previous,
+	}, nil
+}
 
-	if err := systemdConfig.WriteFile(systemdFile); err != nil {
-		logger.Error(ctx, "Failed to write systemd config", "error", err, "file", systemdFile)
-		return fmt.Errorf("failed to write systemd config: %w", err)
+// snapshotUnit 在写入或删除单元文件前记录其当前内容，部署失败时恢复
+func (s *service) snapshotUnit(ctx context.Context, d *deployment, path string) {
+	version, err := s.units.Snapshot(path)
+	if err != nil {
+		logger.Warn(ctx, "Failed to snapshot unit file", "error", err, "file", path)
+		return
 	}
+	d.units = append(d.units, unitSnapshot{path: path, version: version})
+}
 
-	logger.Info(ctx, "Creating systemd config", "service", params.Service, "path", config.WorkingDirectory)
+// revertDeployment 部署失败时恢复部署前的单元文件并重新加载 systemd，再恢复部署前的版本
+func (s *service) revertDeployment(ctx context.Context, serviceName string, d *deployment) {
+	for i := len(d.units) - 1; i >= 0; i-- {
+		unit := d.units[i]
+		var err error
+		if unit.version == 0 {
+			err = s.units.Remove(unit.path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else if name, ok := s.units.Name(unit.path); ok {
+			err = s.units.Restore(name, unit.version)
+		}
+		if err != nil {
+			logger.Warn(ctx, "Failed to restore unit file", "error", err, "file", unit.path, "version", unit.version)
+		}
+	}
+	if len(d.units) > 0 {
+		if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+			logger.Warn(ctx, "Failed to reload systemd daemon", "error", err)
+		}
+	}
+	s.revertRelease(ctx, serviceName, d)
+}
 
-	// 重新加载systemd
-	logger.Info(ctx, "Reloading systemd daemon")
-	if err := systemd.ReloadDaemon(); err != nil {
-		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
-		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+// revertRelease 将 current 切回部署前的版本并删除本次提交的版本
+func (s *service) revertRelease(ctx context.Context, serviceName string, d *deployment) {
+	if err := s.restoreCurrent(serviceName, d.previous); err != nil {
+		logger.Warn(ctx, "Failed to restore previous release", "error", err, "service", serviceName, "release", d.previous)
+		return
 	}
+	if err := s.workspaceMgr.RemoveRelease(serviceName, d.release); err != nil {
+		logger.Warn(ctx, "Failed to remove failed release", "error", err, "service", serviceName, "release", d.release)
+	}
+	logger.Info(ctx, "Restored previous release", "service", serviceName, "release", d.previous, "failed", d.release)
+}
 
-	// 启用和启动服务
-	logger.Info(ctx, "Enabling service", "service", params.Service)
-	if err := systemd.EnableUnit(params.Service); err != nil {
-		logger.Error(ctx, "Failed to enable service", "error", err, "service", params.Service)
-		return fmt.Errorf("failed to enable service: %w", err)
+// releasesInUse 返回服务及其实例的主进程工作目录所在的版本
+func (s *service) releasesInUse(ctx context.Context, serviceName string) []string {
+	names := []string{serviceName}
+	if s.IsTemplate(serviceName) {
+		instances, err := s.listInstances(ctx, serviceName)
+		if err != nil {
+			logger.Warn(ctx, "Failed to list instances", "error", err, "service", serviceName)
+		}
+		names = names[:0]
+		for _, unit := range instances {
+			names = append(names, strings.TrimSuffix(unit.Name, ".service"))
+		}
 	}
 
-	logger.Info(ctx, "Starting service", "service", params.Service)
-	if err := systemd.Send(params.Service, "start", "replace"); err != nil {
-		logger.Error(ctx, "Failed to start service", "error", err, "service", params.Service)
-		return fmt.Errorf("failed to start service: %w", err)
+	var inUse []string
+	for _, name := range names {
+		unit, err := s.systemdMgr.Load(ctx, name)
+		if err != nil || unit.PID == 0 {
+			continue
+		}
+		cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", unit.PID))
+		if err != nil {
+			continue
+		}
+		if id := s.workspaceMgr.ReleaseOf(serviceName, cwd); id != "" {
+			inUse = append(inUse, id)
+		}
 	}
+	return inUse
+}
 
+// finishDeployment 执行 post-start 钩子并发送部署通知
+func (s *service) finishDeployment(ctx context.Context, params *DeployRequest, d *deployment) {
 	// 执行post-start钩子
 	if len(params.Hooks) > 0 {
 		events := s.hookExecutor.ExecuteHooks(ctx, params.Hooks, hooks.HookPostStart, params.Service, map[string]interface{}{
@@ -237,18 +673,27 @@ func (s *service) Deploy(ctx context.Context, params *DeployRequest) error {
 		})
 
 		for _, event := range events {
+			s.publishHookEvent(event)
 			if s.otelReporter != nil {
 				s.otelReporter.ReportHookExecution(ctx, event)
 			}
 		}
 	}
 
+	// 清理超出保留数量的旧版本，保留仍被运行中进程使用的版本
+	if removed, err := s.workspaceMgr.PruneReleases(params.Service, s.releaseLimit, s.releasesInUse(ctx, params.Service)...); err != nil {
+		logger.Warn(ctx, "Failed to prune releases", "error", err, "service", params.Service)
+	} else if len(removed) > 0 {
+		logger.Info(ctx, "Pruned old releases", "service", params.Service, "releases", removed)
+	}
+
 	// 发送服务部署事件通知
 	if s.otelReporter != nil {
 		s.otelReporter.ReportServiceEvent(ctx, params.Service, "deployed", map[string]interface{}{
 			"package_url": params.PackageURL,
-			"service_dir": serviceDir,
-			"log_dir":     logDir,
+			"service_dir": d.serviceDir,
+			"log_dir":     d.logDir,
+			"release":     d.release,
 		})
 	}
 
@@ -256,35 +701,35 @@ func (s *service) Deploy(ctx context.Context, params *DeployRequest) error {
 	if params.Notifications != nil && params.Notifications.Callback != nil && params.Notifications.Callback.Enabled {
 		go s.sendCallbackNotification(ctx, params.Service, "deployed", params.Notifications.Callback)
 	}
-
-	logger.Info(ctx, "Deployment completed successfully", "service", params.Service)
-	return nil
 }
 
-func (s *service) Stop(ctx context.Context, serviceName string) error {
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+func (s *service) Stop(ctx context.Context, serviceName string, opts JobOptions) (*systemd.Job, error) {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "Stop validation failed", "error", err, "service", serviceName)
-		return fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpStop); err != nil {
+		return nil, err
 	}
 
 	logger.Info(ctx, "Stopping service", "service", serviceName)
 
 	// Step 1: Stop the service
-	err := systemd.Send(serviceName, "stop", "replace")
+	job, err := s.runJob(ctx, serviceName, "stop", opts)
 	if err != nil {
 		logger.Error(ctx, "Failed to stop service", "error", err, "service", serviceName)
-		return fmt.Errorf("failed to stop service: %w", err)
+		return job, fmt.Errorf("failed to stop service: %w", err)
 	}
 
 	// Step 2: Disable the service
-	err = systemd.DisableUnit(serviceName)
+	err = s.systemdMgr.DisableUnit(ctx, serviceName, s.paths.Runtime())
 	if err != nil {
 		logger.Error(ctx, "Failed to disable service", "error", err, "service", serviceName)
-		return fmt.Errorf("failed to disable service: %w", err)
+		return job, fmt.Errorf("failed to disable service: %w", err)
 	}
 
-	logger.Info(ctx, "Service stopped successfully", "service", serviceName)
-	return nil
+	logger.Info(ctx, "Service stopped successfully", "service", serviceName, "job", job.ID, "state", job.State)
+	return job, nil
 }
 
 func (s *service) Remove(ctx context.Context, serviceName string) error {
@@ -292,36 +737,66 @@ func (s *service) Remove(ctx context.Context, serviceName string) error {
 		logger.Error(ctx, "Remove validation failed", "error", err, "service", serviceName)
 		return fmt.Errorf("validation failed: %w", err)
 	}
+	template := s.IsTemplate(serviceName)
+	unitName := systemd.UnitName(serviceName)
+	if template {
+		unitName = templateUnit(serviceName)
+	}
+	if err := s.checkUnitOperation(ctx, unitName, policy.OpRemove); err != nil {
+		return err
+	}
 
 	logger.Info(ctx, "Removing service", "service", serviceName)
 
+	// 先移除配对的套接字，避免服务停止后又被新连接激活
+	if s.HasSocket(serviceName) {
+		if err := s.removeSocket(ctx, serviceName); err != nil {
+			return err
+		}
+	}
+
+	// 模板服务：停止全部实例后删除模板单元
+	if template {
+		if err := s.removeTemplate(ctx, serviceName); err != nil {
+			return err
+		}
+		return s.finishRemove(ctx, serviceName)
+	}
+
 	// Step 1: Stop the service
-	err := systemd.Send(serviceName, "stop", "replace")
+	_, err := s.runJob(ctx, serviceName, "stop", JobOptions{Wait: true})
 	if err != nil {
 		logger.Error(ctx, "Failed to stop service", "error", err, "service", serviceName)
 		return fmt.Errorf("failed to stop service: %w", err)
 	}
 
 	// Step 2: Disable the service
-	err = systemd.DisableUnit(serviceName)
+	err = s.systemdMgr.DisableUnit(ctx, serviceName, s.paths.Runtime())
 	if err != nil {
 		logger.Error(ctx, "Failed to disable service", "error", err, "service", serviceName)
 		return fmt.Errorf("failed to disable service: %w", err)
 	}
 
 	// Step 3: Remove the Systemd service file
-	systemdFile := fmt.Sprintf("/etc/systemd/system/%s.service", serviceName)
+	systemdFile := s.paths.Service(serviceName)
 	logger.Info(ctx, "Removing systemd service file", "file", systemdFile)
 
-	if err := os.Remove(systemdFile); err != nil {
+	if err := s.units.Remove(systemdFile); err != nil {
 		logger.Error(ctx, "Failed to remove systemd service file", "error", err, "file", systemdFile)
 		return fmt.Errorf("failed to remove systemd service file: %w", err)
 	}
 
+	// 删除 drop-in 覆盖配置
+	s.removeDropIns(ctx, serviceName)
+
+	return s.finishRemove(ctx, serviceName)
+}
+
+// finishRemove 删除单元文件后重新加载 systemd 并清理工作空间
+func (s *service) finishRemove(ctx context.Context, serviceName string) error {
 	// Step 4: Reload systemd daemon to apply changes
 	logger.Info(ctx, "Reloading systemd daemon")
-	err = systemd.ReloadDaemon()
-	if err != nil {
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
 		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
 		return fmt.Errorf("failed to reload systemd daemon: %w", err)
 	}
@@ -338,35 +813,88 @@ func (s *service) Remove(ctx context.Context, serviceName string) error {
 	return nil
 }
 
-func (s *service) Restart(ctx context.Context, serviceName string) error {
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+func (s *service) Restart(ctx context.Context, serviceName string, opts JobOptions) (*systemd.Job, error) {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "Restart validation failed", "error", err, "service", serviceName)
-		return fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpRestart); err != nil {
+		return nil, err
 	}
 
 	logger.Info(ctx, "Restarting service", "service", serviceName)
 
 	// Step 1: Restart the service
-	err := systemd.Send(serviceName, "restart", "replace")
+	job, err := s.runJob(ctx, serviceName, "restart", opts)
 	if err != nil {
 		logger.Error(ctx, "Failed to restart service", "error", err, "service", serviceName)
-		return fmt.Errorf("failed to restart service: %w", err)
+		return job, fmt.Errorf("failed to restart service: %w", err)
 	}
 
-	logger.Info(ctx, "Service restarted successfully", "service", serviceName)
-	return nil
+	logger.Info(ctx, "Service restarted successfully", "service", serviceName, "job", job.ID, "state", job.State)
+	return job, nil
+}
+
+// Reload 重新加载服务配置，mode 为 reload 或 reload-or-restart
+// 执行记录配置中的 pre_reload/post_reload 钩子，pre_reload 失败时不再重新加载
+func (s *service) Reload(ctx context.Context, serviceName, mode string, opts JobOptions) (*systemd.Job, error) {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
+		logger.Error(ctx, "Reload validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpReload); err != nil {
+		return nil, err
+	}
+	if mode == "" {
+		mode = ReloadModeReload
+	}
+	if mode != ReloadModeReload && mode != ReloadModeReloadOrRestart {
+		return nil, fmt.Errorf("validation failed: %w: %s", ErrInvalidReloadMode, mode)
+	}
+
+	logger.Info(ctx, "Reloading service", "service", serviceName, "mode", mode)
+
+	var serviceHooks []hooks.Hook
+	if config, err := s.workspaceMgr.LoadServiceConfig(baseServiceName(serviceName)); err == nil {
+		serviceHooks = config.Hooks
+	}
+	metadata := map[string]interface{}{"action": "reload", "mode": mode}
+
+	// Step 1: Run pre-reload hooks
+	for _, event := range s.hookExecutor.ExecuteHooks(ctx, serviceHooks, hooks.HookPreReload, serviceName, metadata) {
+		s.publishHookEvent(event)
+		if event.Status == "failure" {
+			logger.Error(ctx, "Pre-reload hook failed", "service", serviceName, "hook", event.HookType, "error", event.Error)
+			return nil, fmt.Errorf("pre-reload hook failed: %s", event.Error)
+		}
+	}
+
+	// Step 2: Reload the service
+	job, err := s.runJob(ctx, serviceName, mode, opts)
+	if err != nil {
+		logger.Error(ctx, "Failed to reload service", "error", err, "service", serviceName)
+		return job, fmt.Errorf("failed to reload service: %w", err)
+	}
+
+	// Step 3: Run post-reload hooks
+	for _, event := range s.hookExecutor.ExecuteHooks(ctx, serviceHooks, hooks.HookPostReload, serviceName, metadata) {
+		s.publishHookEvent(event)
+	}
+
+	logger.Info(ctx, "Service reloaded successfully", "service", serviceName, "job", job.ID, "state", job.State)
+	return job, nil
 }
 
 // GetStatus 获取服务状态
 func (s *service) GetStatus(ctx context.Context, serviceName string) (*systemd.Unit, error) {
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "GetStatus validation failed", "error", err, "service", serviceName)
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	logger.Debug(ctx, "Getting service status", "service", serviceName)
 
-	data, err := systemd.Load(serviceName)
+	data, err := s.systemdMgr.Load(ctx, serviceName)
 	if err != nil {
 		logger.Error(ctx, "Failed to load service status", "error", err, "service", serviceName)
 		return nil, fmt.Errorf("failed to get service status: %w", err)
@@ -375,34 +903,66 @@ func (s *service) GetStatus(ctx context.Context, serviceName string) (*systemd.U
 }
 
 // Start 启动服务
-func (s *service) Start(ctx context.Context, serviceName string) error {
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+func (s *service) Start(ctx context.Context, serviceName string, opts JobOptions) (*systemd.Job, error) {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "Start validation failed", "error", err, "service", serviceName)
-		return fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOperation(ctx, serviceName, policy.OpStart); err != nil {
+		return nil, err
 	}
 
 	logger.Info(ctx, "Starting service", "service", serviceName)
 
-	err := systemd.Send(serviceName, "start", "replace")
+	job, err := s.runJob(ctx, serviceName, "start", opts)
 	if err != nil {
 		logger.Error(ctx, "Failed to start service", "error", err, "service", serviceName)
-		return fmt.Errorf("failed to start service: %w", err)
+		return job, fmt.Errorf("failed to start service: %w", err)
 	}
 
-	logger.Info(ctx, "Service started successfully", "service", serviceName)
-	return nil
+	logger.Info(ctx, "Service started successfully", "service", serviceName, "job", job.ID, "state", job.State)
+	return job, nil
+}
+
+// GetJob 获取 systemd 任务状态
+func (s *service) GetJob(ctx context.Context, jobID string) (*systemd.Job, error) {
+	job, err := s.systemdMgr.GetJob(jobID)
+	if err != nil {
+		logger.Error(ctx, "Failed to get job", "error", err, "job", jobID)
+		return nil, fmt.Errorf("failed to get job %s: %w", jobID, err)
+	}
+	return job, nil
+}
+
+// runJob 发送 systemd 操作，并按选项等待任务结果
+func (s *service) runJob(ctx context.Context, serviceName, action string, opts JobOptions) (*systemd.Job, error) {
+	if !opts.Wait {
+		return s.systemdMgr.Send(ctx, serviceName, action, "replace")
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultJobTimeout
+	}
+	return s.systemdMgr.SendAndWait(ctx, serviceName, action, "replace", timeout)
 }
 
 // GetLogs 获取服务日志
 func (s *service) GetLogs(ctx context.Context, serviceName string, lines int) ([]logs.LogEntry, error) {
-	if err := validator.ValidateServiceName(serviceName); err != nil {
+	if err := validator.ValidateInstanceName(serviceName); err != nil {
 		logger.Error(ctx, "GetLogs validation failed", "error", err, "service", serviceName)
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	logger.Debug(ctx, "Getting service logs", "service", serviceName, "lines", lines)
 
-	logEntries, err := logs.GetServiceLogs(ctx, serviceName, lines)
+	// 模板服务按通配符合并所有实例的日志，journalctl 按时间排序
+	unit := serviceName
+	if s.IsTemplate(serviceName) {
+		unit = serviceName + "@*.service"
+	}
+
+	logEntries, err := logs.GetServiceLogs(ctx, unit, lines, s.systemdMgr.UserMode())
 	if err != nil {
 		logger.Error(ctx, "Failed to get service logs", "error", err, "service", serviceName)
 		return nil, fmt.Errorf("failed to get service logs: %w", err)
@@ -451,7 +1011,7 @@ func (s *service) sendCallbackNotification(ctx context.Context, serviceName, eve
 	}
 }
 
-// ListServices 获取服务列表（过滤掉系统服务，只显示通过API部署的服务）
+// ListServices 获取服务列表（只显示带有所有权标记的服务）
 func (s *service) ListServices(ctx context.Context) ([]ServiceInfo, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -459,32 +1019,49 @@ func (s *service) ListServices(ctx context.Context) ([]ServiceInfo, error) {
 	logger.Info(ctx, "Listing services")
 
 	// 通过systemd D-Bus获取所有服务单元
-	units, err := systemd.ListUnits(ctx)
+	units, err := s.systemdMgr.ListUnits(ctx)
 	if err != nil {
 		logger.Error(ctx, "Failed to list systemd units", "error", err)
 		return nil, fmt.Errorf("failed to list systemd units: %w", err)
 	}
 
 	var services []ServiceInfo
+	sockets := map[string]*SocketInfo{}
+
+	// 模板服务不会出现在单元列表中，从单元目录中找出受管的模板
+	templates := s.listTemplates()
 
-	// 过滤出通过API部署的服务（通常在/etc/systemd/system/目录下，且不是系统内置服务）
+	// 过滤出通过API部署或接管的服务（单元文件或标记 drop-in 带有所有权标记）
 	for _, unit := range units {
+		// 配对的套接字归入同名服务
+		if name, ok := strings.CutSuffix(unit.Name, ".socket"); ok {
+			if s.HasSocket(name) {
+				sockets[name] = &SocketInfo{
+					Name:    unit.Name,
+					Status:  unit.ActiveState,
+					Enabled: unit.UnitFileState == "enabled",
+				}
+			}
+			continue
+		}
+
 		// 只处理.service类型的单元
 		if !strings.HasSuffix(unit.Name, ".service") {
 			continue
 		}
 
-		// 过滤掉系统服务，只保留用户部署的服务
-		if isSystemService(unit.Name) {
+		// 模板实例归入所属模板
+		serviceName := strings.TrimSuffix(unit.Name, ".service")
+		if base := baseServiceName(serviceName); base != serviceName {
+			if instances, ok := templates[base]; ok {
+				templates[base] = append(instances, unit)
+			}
 			continue
 		}
 
 		// 检查服务文件是否在我们管理的目录中
-		serviceName := strings.TrimSuffix(unit.Name, ".service")
-		serviceFile := fmt.Sprintf("/etc/systemd/system/%s.service", serviceName)
-
-		// 检查文件是否存在且可读
-		if _, err := os.Stat(serviceFile); os.IsNotExist(err) {
+		serviceFile := s.paths.Service(serviceName)
+		if !s.isManagedService(serviceName) {
 			continue
 		}
 
@@ -500,32 +1077,98 @@ func (s *service) ListServices(ctx context.Context) ([]ServiceInfo, error) {
 		services = append(services, serviceInfo)
 	}
 
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		services = append(services, s.templateInfo(name, templates[name]))
+	}
+	for i := range services {
+		services[i].Socket = sockets[services[i].Name]
+	}
+
 	logger.Info(ctx, "Services filtered successfully", "total_units", len(units), "filtered_services", len(services))
 	return services, nil
 }
 
-// isSystemService 判断是否为系统服务
-func isSystemService(serviceName string) bool {
-	// 系统内置服务列表（常见的系统服务）
-	systemServices := []string{
-		"systemd-", "dbus", "NetworkManager", "sshd", "chronyd", "rsyslog",
-		"firewalld", "auditd", "crond", "atd", "cups", "avahi-daemon",
-		"bluetooth", "wpa_supplicant", "ModemManager", "accounts-daemon",
-		"polkit", "udisks2", "colord", "rtkit-daemon", "upower",
-		"gdm", "lightdm", "sddm", "getty@", "user@", "session-",
-		"NetworkManager-", "systemd", "kernel", "kthread", "migration",
-		"rcu_", "watchdog", "ksoftirqd", "systemd-resolved", "systemd-networkd",
-		"systemd-timesyncd", "systemd-logind", "systemd-machined", "systemd-importd",
-		"systemd-hostnamed", "systemd-localed", "systemd-timedated",
-		"api-systemd", // 排除自己
+// newSystemdConfig 创建服务单元配置，未配置安装目标时用户管理器下安装到 default.target
+func (s *service) newSystemdConfig(serviceName, startCmd string, config *hooks.ServiceConfig) *SystemdConfig {
+	systemdConfig := NewSystemdConfig(serviceName, config.WorkingDirectory, startCmd, config)
+	systemdConfig.WantedBy = s.installTarget(config)
+	return systemdConfig
+}
+
+// installTarget 返回单元的安装目标，未配置时系统管理器为 multi-user.target，用户管理器为 default.target
+func (s *service) installTarget(config *hooks.ServiceConfig) string {
+	if config.InstallTarget != "" {
+		return config.InstallTarget
+	}
+	if s.systemdMgr.UserMode() {
+		return "default.target"
+	}
+	return "multi-user.target"
+}
+
+// writeUnit 渲染单元并通过单元写入器原子写入
+func (s *service) writeUnit(path string, unit interface{ Render() ([]byte, error) }) error {
+	content, err := unit.Render()
+	if err != nil {
+		return err
 	}
+	return s.units.WriteFile(path, content, 0644)
+}
 
-	// 检查是否匹配系统服务前缀
-	for _, prefix := range systemServices {
-		if strings.HasPrefix(serviceName, prefix) {
-			return true
+// removeDropIns 删除服务的 drop-in，包括 SetUnitProperties 持久化在 .control 目录下的部分
+// 单元目录下的 drop-in 删除前保留到历史版本
+func (s *service) removeDropIns(ctx context.Context, serviceName string) {
+	if entries, err := os.ReadDir(s.dropInDir(serviceName)); err == nil {
+		for _, entry := range entries {
+			if err := s.units.Remove(filepath.Join(s.dropInDir(serviceName), entry.Name())); err != nil {
+				logger.Warn(ctx, "Failed to remove drop-in", "error", err, "dropin", entry.Name())
+			}
 		}
 	}
+	for _, dir := range []string{
+		s.dropInDir(serviceName),
+		s.paths.ControlDir(systemd.UnitName(serviceName)),
+	} {
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Warn(ctx, "Failed to remove drop-ins", "error", err, "dir", dir)
+		}
+	}
+}
 
-	return false
+// publishUnitState 将受管服务的状态变化发布为事件
+func (s *service) publishUnitState(change systemd.UnitStateChange) {
+	if !strings.HasSuffix(change.Unit, ".service") {
+		return
+	}
+	// 在信号分发协程中调用，只查内存集合，避免读取单元文件阻塞任务完成信号
+	if !s.isManagedCached(change.Unit) {
+		return
+	}
+	serviceName := strings.TrimSuffix(change.Unit, ".service")
+
+	s.events.Publish(events.EventUnitState, serviceName, map[string]interface{}{
+		"unit":                  change.Unit,
+		"active_state":          change.ActiveState,
+		"sub_state":             change.SubState,
+		"previous_active_state": change.PreviousActive,
+		"previous_sub_state":    change.PreviousSub,
+	})
+}
+
+// publishHookEvent 将钩子执行结果发布为事件
+func (s *service) publishHookEvent(event *hooks.HookEvent) {
+	data := map[string]interface{}{
+		"hook_type":   string(event.HookType),
+		"status":      event.Status,
+		"duration_ms": event.Duration.Milliseconds(),
+	}
+	if event.Error != "" {
+		data["error"] = event.Error
+	}
+	s.events.Publish(events.EventHook, event.ServiceName, data)
 }
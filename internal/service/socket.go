@@ -0,0 +1,103 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrSocketTemplate Accept=yes 的套接字按连接启动实例，必须与模板服务配对；反之模板服务只能配对 Accept=yes 的套接字
+var ErrSocketTemplate = errors.New("socket accept requires a template service, and a template service requires socket accept")
+
+// SocketStatus 套接字激活服务的状态，服务未收到连接前可能处于 inactive
+type SocketStatus struct {
+	*systemd.Unit
+	Socket *systemd.Unit `json:"socket"`
+}
+
+// SocketInfo 服务列表中配对套接字的信息
+type SocketInfo struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Enabled bool   `json:"enabled"`
+}
+
+// socketUnitName 返回服务配对的套接字单元名
+func socketUnitName(serviceName string) string {
+	return serviceName + ".socket"
+}
+
+// HasSocket 判断服务是否部署了受管的配对套接字
+func (s *service) HasSocket(serviceName string) bool {
+	if strings.Contains(serviceName, "@") {
+		return false
+	}
+	return s.isManagedUnit(socketUnitName(serviceName))
+}
+
+// GetSocketStatus 获取套接字激活服务及其套接字的状态
+func (s *service) GetSocketStatus(ctx context.Context, serviceName string) (*SocketStatus, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetSocketStatus validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if !s.HasSocket(serviceName) {
+		return nil, &systemd.UnitNotFoundError{Unit: socketUnitName(serviceName)}
+	}
+
+	socket, err := s.systemdMgr.Load(ctx, socketUnitName(serviceName))
+	if err != nil {
+		logger.Error(ctx, "Failed to load socket status", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to get socket status: %w", err)
+	}
+	unit, err := s.GetStatus(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return &SocketStatus{Unit: unit, Socket: socket}, nil
+}
+
+// startSocket 启用并启动配对的套接字，调用方需持有锁
+func (s *service) startSocket(ctx context.Context, serviceName string) error {
+	socketUnit := socketUnitName(serviceName)
+	logger.Info(ctx, "Enabling socket", "socket", socketUnit)
+	if err := s.systemdMgr.EnableUnit(ctx, socketUnit, s.paths.Runtime()); err != nil {
+		logger.Error(ctx, "Failed to enable socket", "error", err, "socket", socketUnit)
+		return fmt.Errorf("failed to enable socket: %w", err)
+	}
+
+	logger.Info(ctx, "Starting socket", "socket", socketUnit)
+	if _, err := s.runJob(ctx, socketUnit, "start", JobOptions{Wait: true}); err != nil {
+		logger.Error(ctx, "Failed to start socket", "error", err, "socket", socketUnit)
+		return fmt.Errorf("failed to start socket: %w", err)
+	}
+	return nil
+}
+
+// removeSocket 停止并禁用配对的套接字，删除其单元文件，调用方需持有锁
+func (s *service) removeSocket(ctx context.Context, serviceName string) error {
+	socketUnit := socketUnitName(serviceName)
+	if _, err := s.runJob(ctx, socketUnit, "stop", JobOptions{Wait: true}); err != nil && !systemd.IsUnitNotFound(err) {
+		logger.Error(ctx, "Failed to stop socket", "error", err, "socket", socketUnit)
+		return fmt.Errorf("failed to stop socket: %w", err)
+	}
+	if err := s.systemdMgr.DisableUnit(ctx, socketUnit, s.paths.Runtime()); err != nil {
+		logger.Error(ctx, "Failed to disable socket", "error", err, "socket", socketUnit)
+		return fmt.Errorf("failed to disable socket: %w", err)
+	}
+
+	socketFile := s.paths.Unit(socketUnit)
+	logger.Info(ctx, "Removing systemd socket file", "file", socketFile)
+	if err := s.units.Remove(socketFile); err != nil && !os.IsNotExist(err) {
+		logger.Error(ctx, "Failed to remove systemd socket file", "error", err, "file", socketFile)
+		return fmt.Errorf("failed to remove systemd socket file: %w", err)
+	}
+
+	s.removeDropIns(ctx, socketUnit)
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"api-systemd/internal/pkg/hooks"
+)
+
+// systemd 套接字模板，与同名服务配对；Accept=yes 时按连接启动模板服务的实例
+const socketTpl = `[Unit]
+Description={{.Description}} Socket
+{{managedMarker}}
+
+[Socket]
+{{- range .ListenStream}}
+ListenStream={{.}}
+{{- end}}
+{{- range .ListenDatagram}}
+ListenDatagram={{.}}
+{{- end}}
+{{- if .Accept}}
+Accept=yes
+{{- else}}
+Service={{.ServiceName}}.service
+{{- end}}
+{{- if .SocketUser}}
+SocketUser={{.SocketUser}}
+{{- end}}
+{{- if .SocketMode}}
+SocketMode={{.SocketMode}}
+{{- end}}
+{{- if .FileDescriptorName}}
+FileDescriptorName={{.FileDescriptorName}}
+{{- end}}
+
+[Install]
+WantedBy=sockets.target
+`
+
+// SocketSystemdConfig systemd 套接字配置
+type SocketSystemdConfig struct {
+	*hooks.SocketConfig
+	ServiceName string
+	Description string
+}
+
+// NewSocketSystemdConfig 创建 systemd 套接字配置
+func NewSocketSystemdConfig(serviceName, description string, socketConfig *hooks.SocketConfig) *SocketSystemdConfig {
+	return &SocketSystemdConfig{
+		SocketConfig: socketConfig,
+		ServiceName:  serviceName,
+		Description:  description,
+	}
+}
+
+// Render 校验配置并渲染套接字文件
+func (sc *SocketSystemdConfig) Render() ([]byte, error) {
+	if err := checkText("description", sc.Description); err != nil {
+		return nil, err
+	}
+	values := append(append([]string{sc.SocketMode, sc.FileDescriptorName}, sc.ListenStream...), sc.ListenDatagram...)
+	for _, value := range values {
+		if err := checkText("socket", value); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkUserName("socket_user", sc.SocketUser); err != nil {
+		return nil, err
+	}
+
+	funcMap := template.FuncMap{
+		"managedMarker": func() string { return managedMarker },
+	}
+
+	tmpl, err := template.New("socket").Funcs(funcMap).Parse(socketTpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, sc); err != nil {
+		return nil, fmt.Errorf("failed to render socket: %w", err)
+	}
+	if err := ValidateUnitContent(buf.String(), false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
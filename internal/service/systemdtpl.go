@@ -1,8 +1,8 @@
 package service
 
 import (
+	"bytes"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -13,6 +13,7 @@ import (
 // 统一的 systemd 模板，支持简单和复杂配置
 const systemdTpl = `[Unit]
 Description={{.Description}}
+{{managedMarker}}
 {{- if .After}}
 After={{join .After " "}}
 {{- end}}
@@ -25,19 +26,43 @@ Requires={{join .Requires " "}}
 {{- if .Wants}}
 Wants={{join .Wants " "}}
 {{- end}}
+{{- if .StartLimitIntervalSec}}
+StartLimitIntervalSec={{.StartLimitIntervalSec}}
+{{- end}}
+{{- if .StartLimitBurst}}
+StartLimitBurst={{.StartLimitBurst}}
+{{- end}}
+{{- range .SectionOptions "Unit"}}
+{{.Name}}={{.Value}}
+{{- end}}
 
 [Service]
-Type=simple
+Type={{if .Type}}{{.Type}}{{else}}simple{{end}}
+{{- if .PIDFile}}
+PIDFile={{.PIDFile}}
+{{- end}}
+{{- if .RemainAfterExit}}
+RemainAfterExit=yes
+{{- end}}
 {{- if .User}}
 User={{.User}}
 {{- end}}
 {{- if .Group}}
 Group={{.Group}}
 {{- end}}
+{{- if .UMask}}
+UMask={{.UMask}}
+{{- end}}
+{{- if .Nice}}
+Nice={{.Nice}}
+{{- end}}
 WorkingDirectory={{.WorkingDirectory}}
+{{- range .EnvironmentFile}}
+EnvironmentFile={{.}}
+{{- end}}
 {{- if .Environment}}
 {{- range $key, $value := .Environment}}
-Environment="{{$key}}={{$value}}"
+Environment={{env $key $value}}
 {{- end}}
 {{- end}}
 {{- if .PreStartHooks}}
@@ -46,11 +71,17 @@ ExecStartPre={{.}}
 {{- end}}
 {{- end}}
 ExecStart={{.ExecStart}}
+{{- if .ExecReload}}
+ExecReload={{.ExecReload}}
+{{- end}}
 {{- if .PostStartHooks}}
 {{- range .PostStartHooks}}
 ExecStartPost={{.}}
 {{- end}}
 {{- end}}
+{{- if .ExecStop}}
+ExecStop={{.ExecStop}}
+{{- end}}
 {{- if .PreStopHooks}}
 {{- range .PreStopHooks}}
 ExecStopPre={{.}}
@@ -65,8 +96,29 @@ Restart={{.RestartPolicy}}
 {{- if .RestartDelaySec}}
 RestartSec={{.RestartDelaySecValue}}
 {{- end}}
-{{- if .StartLimitBurst}}
-StartLimitBurst={{.StartLimitBurst}}
+{{- if .TimeoutStartSec}}
+TimeoutStartSec={{.TimeoutStartSec}}
+{{- end}}
+{{- if .TimeoutStopSec}}
+TimeoutStopSec={{.TimeoutStopSec}}
+{{- end}}
+{{- if .KillMode}}
+KillMode={{.KillMode}}
+{{- end}}
+{{- if .KillSignal}}
+KillSignal={{.KillSignal}}
+{{- end}}
+{{- if .SuccessExitStatus}}
+SuccessExitStatus={{join .SuccessExitStatus " "}}
+{{- end}}
+{{- if .StandardOutput}}
+StandardOutput={{.StandardOutput}}
+{{- end}}
+{{- if .StandardError}}
+StandardError={{.StandardError}}
+{{- end}}
+{{- if .LimitNOFILE}}
+LimitNOFILE={{.LimitNOFILE}}
 {{- end}}
 {{- if .MemoryLimit}}
 MemoryMax={{.MemoryLimit}}
@@ -77,9 +129,25 @@ CPUQuota={{.CPUQuota}}
 {{- if .TasksMax}}
 TasksMax={{.TasksMax}}
 {{- end}}
+{{- range .HardeningOptions}}
+{{.Name}}={{.Value}}
+{{- end}}
+{{- range .SectionOptions "Service"}}
+{{.Name}}={{.Value}}
+{{- end}}
 
 [Install]
-WantedBy=multi-user.target
+WantedBy={{.WantedBy}}
+{{- range .SectionOptions "Install"}}
+{{.Name}}={{.Value}}
+{{- end}}
+{{- range $section := .OtherSections}}
+
+[{{$section}}]
+{{- range $.SectionOptions $section}}
+{{.Name}}={{.Value}}
+{{- end}}
+{{- end}}
 `
 
 // SystemdConfig 统一的 systemd 配置结构
@@ -90,6 +158,8 @@ type SystemdConfig struct {
 	PreStopHooks         []string
 	PostStopHooks        []string
 	RestartDelaySecValue int
+	WantedBy             string // 安装目标，未配置时为 multi-user.target，用户管理器下为 default.target
+	HardeningOptions     []hooks.UnitOption
 }
 
 // NewSystemdConfig 创建 systemd 配置
@@ -115,7 +185,14 @@ func NewSystemdConfig(serviceName, workingDirectory, startCmd string, serviceCon
 	systemdConfig := &SystemdConfig{
 		ServiceConfig:        config,
 		RestartDelaySecValue: int(config.RestartDelaySec.Seconds()),
+		WantedBy:             "multi-user.target",
 	}
+	if config.InstallTarget != "" {
+		systemdConfig.WantedBy = config.InstallTarget
+	}
+
+	// 加固后服务仍需写入工作目录和日志目录
+	systemdConfig.HardeningOptions = hardeningOptions(config.Hardening, []string{config.WorkingDirectory, config.Environment["LOG_DIR"]})
 
 	// 从钩子中提取 systemd 原生命令
 	for _, hook := range config.Hooks {
@@ -138,22 +215,121 @@ func NewSystemdConfig(serviceName, workingDirectory, startCmd string, serviceCon
 	return systemdConfig
 }
 
-// WriteFile 写入 systemd 配置文件
-func (sc *SystemdConfig) WriteFile(filename string) error {
+// SectionOptions 返回指定小节的附加配置项
+func (sc *SystemdConfig) SectionOptions(section string) []hooks.UnitOption {
+	var options []hooks.UnitOption
+	for _, opt := range sc.ExtraOptions {
+		if opt.Section == section {
+			options = append(options, opt)
+		}
+	}
+	return options
+}
+
+// OtherSections 返回附加配置项中 Unit、Service、Install 以外的小节，按首次出现顺序排列
+func (sc *SystemdConfig) OtherSections() []string {
+	var sections []string
+	seen := map[string]bool{"Unit": true, "Service": true, "Install": true}
+	for _, opt := range sc.ExtraOptions {
+		if !seen[opt.Section] {
+			seen[opt.Section] = true
+			sections = append(sections, opt.Section)
+		}
+	}
+	return sections
+}
+
+// validate 检查将写入单元文件的每个取值，防止通过换行、引号等注入额外的配置项
+func (sc *SystemdConfig) validate() error {
+	if err := checkText("description", sc.Description); err != nil {
+		return err
+	}
+	for field, names := range map[string][]string{
+		"after": sc.After, "before": sc.Before, "requires": sc.Requires, "wants": sc.Wants,
+	} {
+		if err := checkUnitNames(field, names); err != nil {
+			return err
+		}
+	}
+	if err := checkUserName("user", sc.User); err != nil {
+		return err
+	}
+	if err := checkUserName("group", sc.Group); err != nil {
+		return err
+	}
+	if err := checkText("working_directory", sc.WorkingDirectory); err != nil {
+		return err
+	}
+	for _, file := range sc.EnvironmentFile {
+		if err := checkText("environment_file", file); err != nil {
+			return err
+		}
+	}
+	for key, value := range sc.Environment {
+		if _, err := quoteEnvironment(key, value); err != nil {
+			return err
+		}
+	}
+
+	commands := map[string]string{"exec_start": sc.ExecStart, "exec_reload": sc.ExecReload, "exec_stop": sc.ExecStop}
+	for field, command := range commands {
+		if err := checkCommand(field, command); err != nil {
+			return err
+		}
+	}
+	for _, list := range [][]string{sc.PreStartHooks, sc.PostStartHooks, sc.PreStopHooks, sc.PostStopHooks} {
+		for _, command := range list {
+			if err := checkCommand("hook command", command); err != nil {
+				return err
+			}
+		}
+	}
+
+	values := map[string]string{
+		"type": sc.Type, "pid_file": sc.PIDFile, "umask": sc.UMask, "restart_policy": sc.RestartPolicy,
+		"start_limit_interval_sec": sc.StartLimitIntervalSec, "timeout_start_sec": sc.TimeoutStartSec,
+		"timeout_stop_sec": sc.TimeoutStopSec, "kill_mode": sc.KillMode, "kill_signal": sc.KillSignal,
+		"standard_output": sc.StandardOutput, "standard_error": sc.StandardError, "limit_nofile": sc.LimitNOFILE,
+		"memory_limit": sc.MemoryLimit, "cpu_quota": sc.CPUQuota, "install_target": sc.WantedBy,
+		"success_exit_status": strings.Join(sc.SuccessExitStatus, " "),
+	}
+	for field, value := range values {
+		if err := checkLineValue(field, value); err != nil {
+			return err
+		}
+	}
+
+	for _, opt := range append(append([]hooks.UnitOption{}, sc.ExtraOptions...), sc.HardeningOptions...) {
+		if err := checkOption(opt); err != nil {
+			return err
+		}
+	}
+	return checkProtectHome(sc.Hardening, []string{sc.WorkingDirectory, sc.Environment["LOG_DIR"]})
+}
+
+// Render 校验配置并渲染单元文件，渲染结果还需通过结构校验
+func (sc *SystemdConfig) Render() ([]byte, error) {
+	if err := sc.validate(); err != nil {
+		return nil, err
+	}
+
 	funcMap := template.FuncMap{
-		"join": strings.Join,
+		"join":          strings.Join,
+		"env":           quoteEnvironment,
+		"managedMarker": func() string { return managedMarker },
 	}
 
 	tmpl, err := template.New("systemd").Funcs(funcMap).Parse(systemdTpl)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %w", err)
+		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
 
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, sc); err != nil {
+		return nil, fmt.Errorf("failed to render unit: %w", err)
 	}
-	defer file.Close()
-
-	return tmpl.Execute(file, sc)
+	if err := ValidateUnitContent(buf.String(), true); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
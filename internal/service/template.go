@@ -0,0 +1,321 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/events"
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxInstances 模板服务允许扩容的最大实例数
+const MaxInstances = 100
+
+var (
+	// ErrNotTemplate 服务不是模板服务
+	ErrNotTemplate = errors.New("service is not a template unit")
+	// ErrInvalidInstances 实例数超出范围
+	ErrInvalidInstances = fmt.Errorf("instances must be between 0 and %d", MaxInstances)
+)
+
+// ScaleResult 扩缩容结果
+type ScaleResult struct {
+	Service   string         `json:"service"`
+	Instances int            `json:"instances"` // 目标实例数
+	Started   []string       `json:"started"`   // 启动（或确认运行中）的实例单元
+	Stopped   []string       `json:"stopped"`   // 停止并禁用的多余实例单元
+	Jobs      []*systemd.Job `json:"jobs,omitempty"`
+}
+
+// TemplateStatus 模板服务所有实例的汇总状态
+type TemplateStatus struct {
+	Service     string          `json:"service"`
+	Template    string          `json:"template"`     // 模板单元名，如 worker@.service
+	ActiveState string          `json:"active_state"` // active、degraded、failed、activating 或 inactive
+	Active      int             `json:"active"`
+	Failed      int             `json:"failed"`
+	Instances   []*systemd.Unit `json:"instances"`
+	Socket      *systemd.Unit   `json:"socket,omitempty"` // Accept=yes 的配对套接字，实例按连接启动
+}
+
+// baseServiceName 返回实例名对应的服务名，如 worker@1 返回 worker
+func baseServiceName(serviceName string) string {
+	base, _, _ := strings.Cut(serviceName, "@")
+	return base
+}
+
+// templateUnit 返回服务的模板单元名，如 worker 返回 worker@.service
+func templateUnit(serviceName string) string {
+	return systemd.UnitName(serviceName + "@")
+}
+
+// IsTemplate 判断服务是否以模板单元部署（单元目录中存在 name@.service）
+func (s *service) IsTemplate(serviceName string) bool {
+	if strings.Contains(serviceName, "@") {
+		return false
+	}
+	_, err := os.Stat(s.paths.Unit(templateUnit(serviceName)))
+	return err == nil
+}
+
+// listInstances 返回模板已加载的实例单元，按实例名排序（数字实例按数值）
+func (s *service) listInstances(ctx context.Context, serviceName string) ([]*systemd.Unit, error) {
+	units, err := s.systemdMgr.ListUnits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list systemd units: %w", err)
+	}
+
+	prefix := serviceName + "@"
+	var instances []*systemd.Unit
+	for _, unit := range units {
+		if strings.HasPrefix(unit.Name, prefix) && strings.HasSuffix(unit.Name, ".service") && unit.Name != templateUnit(serviceName) {
+			instances = append(instances, unit)
+		}
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		a, aErr := strconv.Atoi(instanceOf(instances[i].Name))
+		b, bErr := strconv.Atoi(instanceOf(instances[j].Name))
+		if aErr == nil && bErr == nil {
+			return a < b
+		}
+		return instances[i].Name < instances[j].Name
+	})
+	return instances, nil
+}
+
+// instanceOf 返回实例单元名中的实例部分，如 worker@1.service 返回 1
+func instanceOf(unitName string) string {
+	_, instance, _ := strings.Cut(strings.TrimSuffix(unitName, ".service"), "@")
+	return instance
+}
+
+// listTemplates 返回单元目录中受管的模板服务名，值用于收集已加载的实例
+func (s *service) listTemplates() map[string][]*systemd.Unit {
+	templates := map[string][]*systemd.Unit{}
+	entries, err := os.ReadDir(s.paths.Dir())
+	if err != nil {
+		return templates
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), "@.service")
+		if ok && validator.ValidateServiceName(name) == nil && s.isManagedUnit(entry.Name()) {
+			templates[name] = nil
+		}
+	}
+	return templates
+}
+
+// templateInfo 生成模板服务的列表项，状态为实例的汇总状态
+func (s *service) templateInfo(serviceName string, instances []*systemd.Unit) ServiceInfo {
+	info := ServiceInfo{
+		Name:      serviceName,
+		Path:      s.paths.Unit(templateUnit(serviceName)),
+		Template:  true,
+		Instances: []ServiceInfo{},
+	}
+	for _, unit := range instances {
+		enabled := unit.UnitFileState == "enabled"
+		info.Instances = append(info.Instances, ServiceInfo{
+			Name:        strings.TrimSuffix(unit.Name, ".service"),
+			Status:      unit.ActiveState,
+			Description: unit.Description,
+			Path:        info.Path,
+			Enabled:     enabled,
+		})
+		info.Enabled = info.Enabled || enabled
+		if info.Description == "" {
+			info.Description = unit.Description
+		}
+	}
+	info.Status, _, _ = aggregateState(instances)
+	return info
+}
+
+// aggregateState 汇总实例的运行状态
+func aggregateState(instances []*systemd.Unit) (state string, active, failed int) {
+	activating := 0
+	for _, unit := range instances {
+		switch unit.ActiveState {
+		case "active", "reloading":
+			active++
+		case "failed":
+			failed++
+		case "activating":
+			activating++
+		}
+	}
+
+	switch {
+	case failed > 0:
+		return "failed", active, failed
+	case active > 0 && active == len(instances):
+		return "active", active, failed
+	case active > 0:
+		return "degraded", active, failed
+	case activating > 0:
+		return "activating", active, failed
+	}
+	return "inactive", active, failed
+}
+
+// Scale 将模板服务扩缩容到 instances 个编号实例（name@1 到 name@N）
+// 启用并启动缺少的实例，停止并禁用编号大于 N 的实例；非数字编号的实例不受影响
+func (s *service) Scale(ctx context.Context, serviceName string, instances int, opts JobOptions) (*ScaleResult, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "Scale validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if instances < 0 || instances > MaxInstances {
+		return nil, fmt.Errorf("validation failed: %w", ErrInvalidInstances)
+	}
+	if !s.IsTemplate(serviceName) {
+		return nil, fmt.Errorf("%w: %s", ErrNotTemplate, templateUnit(serviceName))
+	}
+	if err := s.checkUnitOperation(ctx, templateUnit(serviceName), policy.OpScale); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.scaleInstances(ctx, serviceName, instances, "start", opts)
+}
+
+// scaleInstances 调整编号实例的数量，保留的实例执行 action（start 或 restart），调用方需持有锁
+func (s *service) scaleInstances(ctx context.Context, serviceName string, instances int, action string, opts JobOptions) (*ScaleResult, error) {
+	logger.Info(ctx, "Scaling service", "service", serviceName, "instances", instances)
+
+	current, err := s.listInstances(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "Failed to list instances", "error", err, "service", serviceName)
+		return nil, err
+	}
+
+	result := &ScaleResult{Service: serviceName, Instances: instances, Started: []string{}, Stopped: []string{}}
+
+	// 先停止多余的实例，避免扩容失败时实例数超出目标
+	for _, unit := range current {
+		n, err := strconv.Atoi(instanceOf(unit.Name))
+		if err != nil || n <= instances {
+			continue
+		}
+		instance := strings.TrimSuffix(unit.Name, ".service")
+		if _, err := s.runJob(ctx, instance, "stop", JobOptions{Wait: true, Timeout: opts.Timeout}); err != nil {
+			logger.Error(ctx, "Failed to stop instance", "error", err, "instance", unit.Name)
+			return result, fmt.Errorf("failed to stop instance %s: %w", unit.Name, err)
+		}
+		if err := s.systemdMgr.DisableUnit(ctx, instance, s.paths.Runtime()); err != nil {
+			logger.Error(ctx, "Failed to disable instance", "error", err, "instance", unit.Name)
+			return result, fmt.Errorf("failed to disable instance %s: %w", unit.Name, err)
+		}
+		result.Stopped = append(result.Stopped, unit.Name)
+	}
+
+	for i := 1; i <= instances; i++ {
+		instance := serviceName + "@" + strconv.Itoa(i)
+		if err := s.systemdMgr.EnableUnit(ctx, instance, s.paths.Runtime()); err != nil {
+			logger.Error(ctx, "Failed to enable instance", "error", err, "instance", instance)
+			return result, fmt.Errorf("failed to enable instance %s: %w", instance, err)
+		}
+		job, err := s.runJob(ctx, instance, action, opts)
+		if err != nil {
+			logger.Error(ctx, "Failed to start instance", "error", err, "instance", instance)
+			return result, fmt.Errorf("failed to start instance %s: %w", instance, err)
+		}
+		result.Started = append(result.Started, systemd.UnitName(instance))
+		result.Jobs = append(result.Jobs, job)
+	}
+
+	s.events.Publish(events.EventScale, serviceName, map[string]interface{}{
+		"instances": instances,
+		"started":   result.Started,
+		"stopped":   result.Stopped,
+	})
+
+	logger.Info(ctx, "Service scaled successfully", "service", serviceName, "instances", instances, "stopped", len(result.Stopped))
+	return result, nil
+}
+
+// GetTemplateStatus 获取模板服务所有已加载实例的状态及汇总状态
+func (s *service) GetTemplateStatus(ctx context.Context, serviceName string) (*TemplateStatus, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetTemplateStatus validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if !s.IsTemplate(serviceName) {
+		return nil, fmt.Errorf("%w: %s", ErrNotTemplate, templateUnit(serviceName))
+	}
+
+	units, err := s.listInstances(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "Failed to list instances", "error", err, "service", serviceName)
+		return nil, err
+	}
+
+	// 逐个加载实例的运行时信息
+	instances := make([]*systemd.Unit, 0, len(units))
+	for _, unit := range units {
+		data, err := s.systemdMgr.Load(ctx, strings.TrimSuffix(unit.Name, ".service"))
+		if err != nil {
+			logger.Warn(ctx, "Failed to load instance status", "error", err, "instance", unit.Name)
+			data = unit
+		}
+		instances = append(instances, data)
+	}
+
+	status := &TemplateStatus{
+		Service:   serviceName,
+		Template:  templateUnit(serviceName),
+		Instances: instances,
+	}
+	status.ActiveState, status.Active, status.Failed = aggregateState(instances)
+
+	if s.HasSocket(serviceName) {
+		socket, err := s.systemdMgr.Load(ctx, socketUnitName(serviceName))
+		if err != nil {
+			logger.Warn(ctx, "Failed to load socket status", "error", err, "service", serviceName)
+		} else {
+			status.Socket = socket
+		}
+	}
+	return status, nil
+}
+
+// removeTemplate 停止并禁用模板的全部实例，删除模板单元文件及其 drop-in，调用方需持有锁
+func (s *service) removeTemplate(ctx context.Context, serviceName string) error {
+	instances, err := s.listInstances(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "Failed to list instances", "error", err, "service", serviceName)
+		return err
+	}
+
+	for _, unit := range instances {
+		instance := strings.TrimSuffix(unit.Name, ".service")
+		if _, err := s.runJob(ctx, instance, "stop", JobOptions{Wait: true}); err != nil {
+			logger.Error(ctx, "Failed to stop instance", "error", err, "instance", unit.Name)
+			return fmt.Errorf("failed to stop instance %s: %w", unit.Name, err)
+		}
+		if err := s.systemdMgr.DisableUnit(ctx, instance, s.paths.Runtime()); err != nil {
+			logger.Error(ctx, "Failed to disable instance", "error", err, "instance", unit.Name)
+			return fmt.Errorf("failed to disable instance %s: %w", unit.Name, err)
+		}
+	}
+
+	templateFile := s.paths.Unit(templateUnit(serviceName))
+	logger.Info(ctx, "Removing systemd template file", "file", templateFile)
+	if err := s.units.Remove(templateFile); err != nil {
+		logger.Error(ctx, "Failed to remove systemd template file", "error", err, "file", templateFile)
+		return fmt.Errorf("failed to remove systemd template file: %w", err)
+	}
+
+	s.removeDropIns(ctx, serviceName+"@")
+	return nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+
+	"api-systemd/internal/pkg/systemd"
+)
+
+// unitsInStates 按状态构造实例列表
+func unitsInStates(states ...string) []*systemd.Unit {
+	units := make([]*systemd.Unit, 0, len(states))
+	for _, state := range states {
+		units = append(units, &systemd.Unit{ActiveState: state})
+	}
+	return units
+}
+
+func TestAggregateState(t *testing.T) {
+	tests := []struct {
+		name           string
+		states         []string
+		want           string
+		active, failed int
+	}{
+		{name: "no instances", states: nil, want: "inactive"},
+		{name: "all active", states: []string{"active", "reloading"}, want: "active", active: 2},
+		{name: "partially active", states: []string{"active", "inactive"}, want: "degraded", active: 1},
+		{name: "any failed", states: []string{"active", "active", "failed"}, want: "failed", active: 2, failed: 1},
+		{name: "starting", states: []string{"activating", "inactive"}, want: "activating"},
+		{name: "active with starting", states: []string{"active", "activating"}, want: "degraded", active: 1},
+		{name: "all inactive", states: []string{"inactive", "deactivating"}, want: "inactive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, active, failed := aggregateState(unitsInStates(tt.states...))
+			if state != tt.want || active != tt.active || failed != tt.failed {
+				t.Fatalf("aggregateState = %s, %d, %d, want %s, %d, %d", state, active, failed, tt.want, tt.active, tt.failed)
+			}
+		})
+	}
+}
+
+func TestTemplateNames(t *testing.T) {
+	tests := []struct {
+		unit, instance string
+	}{
+		{"worker@1.service", "1"},
+		{"worker@blue.service", "blue"},
+		{"worker@.service", ""},
+		{"worker.service", ""},
+	}
+	for _, tt := range tests {
+		if got := instanceOf(tt.unit); got != tt.instance {
+			t.Errorf("instanceOf(%q) = %q, want %q", tt.unit, got, tt.instance)
+		}
+	}
+
+	if got := baseServiceName("worker@3"); got != "worker" {
+		t.Errorf("baseServiceName(worker@3) = %q", got)
+	}
+	if got := baseServiceName("worker"); got != "worker" {
+		t.Errorf("baseServiceName(worker) = %q", got)
+	}
+	if got := templateUnit("worker"); got != "worker@.service" {
+		t.Errorf("templateUnit(worker) = %q", got)
+	}
+}
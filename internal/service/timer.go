@@ -0,0 +1,288 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/events"
+	"api-systemd/internal/pkg/hooks"
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/validator"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DeployTimerRequest 定时任务部署请求
+type DeployTimerRequest struct {
+	DeployRequest
+	Timer *hooks.TimerConfig `json:"timer"` // 定时器配置
+}
+
+// DeployTimer 部署定时任务：生成 oneshot 服务和配对的定时器
+func (s *service) DeployTimer(ctx context.Context, params *DeployTimerRequest) (err error) {
+	// 参数验证
+	if err := validator.ValidateServiceName(params.Service); err != nil {
+		logger.Error(ctx, "DeployTimer validation failed", "error", err, "service", params.Service)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := validator.ValidateTimerConfig(params.Timer); err != nil {
+		logger.Error(ctx, "DeployTimer validation failed", "error", err, "service", params.Service)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	// 定时任务由定时器触发，不能再配对套接字
+	if params.Config != nil && params.Config.Socket != nil {
+		return fmt.Errorf("validation failed: %w: socket is not supported for timer services", validator.ErrInvalidConfig)
+	}
+
+	// 并发控制
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 不覆盖手工编写的同名单元
+	for _, unit := range []string{params.Service + ".service", timerUnitName(params.Service)} {
+		if err := s.checkDeployTarget(ctx, unit); err != nil {
+			return err
+		}
+	}
+
+	logger.Info(ctx, "Starting timer deployment", "service", params.Service, "url", params.PackageURL)
+
+	// 发布部署事件
+	s.events.Publish(events.EventDeploy, params.Service, map[string]interface{}{
+		"phase":       "started",
+		"kind":        "timer",
+		"package_url": params.PackageURL,
+	})
+	defer func() {
+		data := map[string]interface{}{
+			"phase":       "completed",
+			"kind":        "timer",
+			"package_url": params.PackageURL,
+		}
+		if err != nil {
+			data["phase"] = "failed"
+			data["error"] = err.Error()
+		}
+		s.events.Publish(events.EventDeploy, params.Service, data)
+	}()
+
+	// 准备部署：目录、钩子、产物和服务配置
+	d, err := s.prepareDeployment(ctx, &params.DeployRequest)
+	if err != nil {
+		return err
+	}
+	// 后续步骤失败时恢复部署前的单元文件和版本
+	defer func() {
+		if err != nil {
+			s.revertDeployment(ctx, params.Service, d)
+		}
+	}()
+	config := d.config
+
+	// 定时任务每次运行到结束，由定时器负责再次触发
+	config.Type = "oneshot"
+	config.RestartPolicy = "no"
+
+	// 写入 service 配置
+	serviceFile := s.paths.Service(params.Service)
+	systemdConfig := s.newSystemdConfig(params.Service, params.StartCommand, config)
+	s.snapshotUnit(ctx, d, serviceFile)
+	if err := s.writeUnit(serviceFile, systemdConfig); err != nil {
+		logger.Error(ctx, "Failed to write systemd config", "error", err, "file", serviceFile)
+		return fmt.Errorf("failed to write systemd config: %w", err)
+	}
+
+	// 写入 timer 配置
+	timerFile := s.paths.Unit(timerUnitName(params.Service))
+	timerConfig := NewTimerSystemdConfig(params.Service, config.Description, params.Timer)
+	s.snapshotUnit(ctx, d, timerFile)
+	if err := s.writeUnit(timerFile, timerConfig); err != nil {
+		logger.Error(ctx, "Failed to write timer config", "error", err, "file", timerFile)
+		return fmt.Errorf("failed to write timer config: %w", err)
+	}
+
+	logger.Info(ctx, "Created timer config", "service", params.Service, "timer", timerFile)
+
+	// 记录部署配置，供一次性命令等操作复用
+	if err := s.workspaceMgr.SaveServiceConfig(params.Service, config); err != nil {
+		logger.Warn(ctx, "Failed to save service config", "error", err, "service", params.Service)
+	}
+
+	// 重新加载systemd
+	logger.Info(ctx, "Reloading systemd daemon")
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+
+	// 启用和启动定时器（服务由定时器触发）
+	timerUnit := timerUnitName(params.Service)
+	logger.Info(ctx, "Enabling timer", "timer", timerUnit)
+	if err := s.systemdMgr.EnableUnit(ctx, timerUnit, s.paths.Runtime()); err != nil {
+		logger.Error(ctx, "Failed to enable timer", "error", err, "timer", timerUnit)
+		return fmt.Errorf("failed to enable timer: %w", err)
+	}
+
+	logger.Info(ctx, "Starting timer", "timer", timerUnit)
+	if _, err := s.runJob(ctx, timerUnit, "start", JobOptions{Wait: true}); err != nil {
+		logger.Error(ctx, "Failed to start timer", "error", err, "timer", timerUnit)
+		return fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	s.finishDeployment(ctx, &params.DeployRequest, d)
+
+	logger.Info(ctx, "Timer deployment completed successfully", "service", params.Service)
+	return nil
+}
+
+// ListTimers 获取定时任务列表
+func (s *service) ListTimers(ctx context.Context) ([]*systemd.Timer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	units, err := s.systemdMgr.ListUnits(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to list systemd units", "error", err)
+		return nil, fmt.Errorf("failed to list systemd units: %w", err)
+	}
+
+	var timers []*systemd.Timer
+	for _, unit := range units {
+		if !strings.HasSuffix(unit.Name, ".timer") {
+			continue
+		}
+
+		// 只返回通过API部署的定时任务
+		serviceName := strings.TrimSuffix(unit.Name, ".timer")
+		if !s.isManagedTimer(serviceName) {
+			continue
+		}
+
+		timer, err := s.systemdMgr.LoadTimer(ctx, unit.Name)
+		if err != nil {
+			logger.Warn(ctx, "Failed to load timer", "error", err, "timer", unit.Name)
+			continue
+		}
+		timers = append(timers, timer)
+	}
+
+	logger.Info(ctx, "Timers listed successfully", "total_units", len(units), "timers", len(timers))
+	return timers, nil
+}
+
+// GetTimer 获取定时任务状态
+func (s *service) GetTimer(ctx context.Context, serviceName string) (*systemd.Timer, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetTimer validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	timer, err := s.systemdMgr.LoadTimer(ctx, timerUnitName(serviceName))
+	if err != nil {
+		logger.Error(ctx, "Failed to load timer", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("failed to get timer status: %w", err)
+	}
+	return timer, nil
+}
+
+// TriggerTimer 立即运行一次定时任务
+func (s *service) TriggerTimer(ctx context.Context, serviceName string, opts JobOptions) (*systemd.Job, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "TriggerTimer validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if !s.isManagedTimer(serviceName) {
+		return nil, &systemd.UnitNotFoundError{Unit: timerUnitName(serviceName)}
+	}
+	if err := s.authorize(ctx, systemd.UnitName(serviceName), policy.OpTrigger); err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "Triggering timer service", "service", serviceName)
+
+	// oneshot 服务的启动任务在本次运行结束后才完成，任务结果即运行结果
+	job, err := s.runJob(ctx, serviceName, "start", opts)
+	if err != nil {
+		logger.Error(ctx, "Failed to trigger timer service", "error", err, "service", serviceName)
+		return job, fmt.Errorf("failed to trigger timer service: %w", err)
+	}
+
+	logger.Info(ctx, "Timer service triggered", "service", serviceName, "job", job.ID, "state", job.State)
+	return job, nil
+}
+
+// RemoveTimer 移除定时任务及其配对的服务
+func (s *service) RemoveTimer(ctx context.Context, serviceName string) error {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "RemoveTimer validation failed", "error", err, "service", serviceName)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err := s.checkUnitOperation(ctx, timerUnitName(serviceName), policy.OpRemove); err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, systemd.UnitName(serviceName), policy.OpRemove); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logger.Info(ctx, "Removing timer", "service", serviceName)
+
+	// Step 1: Stop and disable the timer, then stop any running instance
+	timerUnit := timerUnitName(serviceName)
+	if _, err := s.runJob(ctx, timerUnit, "stop", JobOptions{Wait: true}); err != nil {
+		logger.Error(ctx, "Failed to stop timer", "error", err, "timer", timerUnit)
+		return fmt.Errorf("failed to stop timer: %w", err)
+	}
+	if err := s.systemdMgr.DisableUnit(ctx, timerUnit, s.paths.Runtime()); err != nil {
+		logger.Error(ctx, "Failed to disable timer", "error", err, "timer", timerUnit)
+		return fmt.Errorf("failed to disable timer: %w", err)
+	}
+	if _, err := s.runJob(ctx, serviceName, "stop", JobOptions{Wait: true}); err != nil {
+		logger.Error(ctx, "Failed to stop timer service", "error", err, "service", serviceName)
+		return fmt.Errorf("failed to stop timer service: %w", err)
+	}
+
+	// Step 2: Remove the timer and service files
+	for _, file := range []string{
+		s.paths.Unit(timerUnitName(serviceName)),
+		s.paths.Service(serviceName),
+	} {
+		logger.Info(ctx, "Removing systemd unit file", "file", file)
+		if err := s.units.Remove(file); err != nil && !os.IsNotExist(err) {
+			logger.Error(ctx, "Failed to remove systemd unit file", "error", err, "file", file)
+			return fmt.Errorf("failed to remove systemd unit file: %w", err)
+		}
+	}
+
+	s.removeDropIns(ctx, serviceName)
+
+	// Step 3: Reload systemd daemon to apply changes
+	logger.Info(ctx, "Reloading systemd daemon")
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+
+	// Step 4: Clean up service directories
+	if err := s.workspaceMgr.CleanupService(serviceName); err != nil {
+		logger.Warn(ctx, "Failed to cleanup service directories", "error", err, "service", serviceName)
+	}
+
+	logger.Info(ctx, "Timer removed successfully", "service", serviceName)
+	return nil
+}
+
+// timerUnitName 返回服务配对的定时器单元名
+func timerUnitName(serviceName string) string {
+	return serviceName + ".timer"
+}
+
+// isManagedTimer 判断定时器是否由本系统管理
+func (s *service) isManagedTimer(serviceName string) bool {
+	return s.isManagedUnit(timerUnitName(serviceName))
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"api-systemd/internal/pkg/hooks"
+)
+
+// systemd 定时器模板，与同名的 oneshot 服务配对
+const timerTpl = `[Unit]
+Description={{.Description}} Timer
+{{managedMarker}}
+
+[Timer]
+{{- range .OnCalendar}}
+OnCalendar={{.}}
+{{- end}}
+{{- if .OnBootSec}}
+OnBootSec={{.OnBootSec}}
+{{- end}}
+{{- if .OnUnitActiveSec}}
+OnUnitActiveSec={{.OnUnitActiveSec}}
+{{- end}}
+{{- if .Persistent}}
+Persistent=true
+{{- end}}
+{{- if .RandomizedDelaySec}}
+RandomizedDelaySec={{.RandomizedDelaySec}}
+{{- end}}
+Unit={{.ServiceName}}.service
+
+[Install]
+WantedBy=timers.target
+`
+
+// TimerSystemdConfig systemd 定时器配置
+type TimerSystemdConfig struct {
+	*hooks.TimerConfig
+	ServiceName string
+	Description string
+}
+
+// NewTimerSystemdConfig 创建 systemd 定时器配置
+func NewTimerSystemdConfig(serviceName, description string, timerConfig *hooks.TimerConfig) *TimerSystemdConfig {
+	return &TimerSystemdConfig{
+		TimerConfig: timerConfig,
+		ServiceName: serviceName,
+		Description: description,
+	}
+}
+
+// Render 校验配置并渲染定时器文件
+func (tc *TimerSystemdConfig) Render() ([]byte, error) {
+	if err := checkText("description", tc.Description); err != nil {
+		return nil, err
+	}
+	values := append([]string{tc.OnBootSec, tc.OnUnitActiveSec, tc.RandomizedDelaySec}, tc.OnCalendar...)
+	for _, value := range values {
+		if err := checkLineValue("timer", value); err != nil {
+			return nil, err
+		}
+	}
+
+	funcMap := template.FuncMap{
+		"managedMarker": func() string { return managedMarker },
+	}
+
+	tmpl, err := template.New("timer").Funcs(funcMap).Parse(timerTpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, tc); err != nil {
+		return nil, fmt.Errorf("failed to render timer: %w", err)
+	}
+	if err := ValidateUnitContent(buf.String(), false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnsafeUnitValue 配置值无法安全写入单元文件
+var ErrUnsafeUnitValue = errors.New("unsafe unit value")
+
+var (
+	// envNamePattern 环境变量名
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// userNamePattern systemd 接受的用户名、用户组名或数字 ID
+	userNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$|^[0-9]+$`)
+	// unitNamePattern 依赖中引用的单元名
+	unitNamePattern = regexp.MustCompile(`^[A-Za-z0-9:_.@\\-]+$`)
+	// sectionPattern 小节名，包括 X- 开头的自定义小节
+	sectionPattern = regexp.MustCompile(`^(Unit|Service|Install|Timer|Socket|Path|Slice|Scope|Mount|Automount|Swap|X-[A-Za-z0-9-]+)$`)
+	// optionNamePattern 配置项名，包括 X- 开头的自定义配置项
+	optionNamePattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*|X-[A-Za-z0-9-]+)$`)
+)
+
+// unitSpecifiers systemd 支持的说明符，见 systemd.unit(5)
+const unitSpecifiers = "aAbBCdEfgGhHiIjJlLmMnNopPqsStTuUvVwWyY%"
+
+// checkControl 检查配置值不含换行等控制字符
+func checkControl(field, value string) error {
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("%w: %s is not valid UTF-8", ErrUnsafeUnitValue, field)
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) && r != '\t' {
+			return fmt.Errorf("%w: %s contains control character %q", ErrUnsafeUnitValue, field, r)
+		}
+	}
+	return nil
+}
+
+// checkLineValue 检查单行配置值：不允许控制字符，行尾反斜杠会与下一行拼接
+func checkLineValue(field, value string) error {
+	if err := checkControl(field, value); err != nil {
+		return err
+	}
+	if strings.HasSuffix(value, "\\") {
+		return fmt.Errorf("%w: %s ends with a backslash", ErrUnsafeUnitValue, field)
+	}
+	return nil
+}
+
+// checkSpecifiers 检查 % 说明符，字面的百分号需要写成 %%
+func checkSpecifiers(field, value string) error {
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' {
+			continue
+		}
+		if i+1 >= len(value) || !strings.ContainsRune(unitSpecifiers, rune(value[i+1])) {
+			return fmt.Errorf("%w: %s contains an invalid specifier, use %%%% for a literal percent sign", ErrUnsafeUnitValue, field)
+		}
+		i++
+	}
+	return nil
+}
+
+// checkText 检查会做说明符展开的单行文本，如 Description=、WorkingDirectory=
+func checkText(field, value string) error {
+	if err := checkLineValue(field, value); err != nil {
+		return err
+	}
+	return checkSpecifiers(field, value)
+}
+
+// checkCommand 检查 Exec*= 命令行，引号必须成对出现
+func checkCommand(field, value string) error {
+	if err := checkText(field, value); err != nil {
+		return err
+	}
+	if _, err := SplitUnitWords(value); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUnsafeUnitValue, field, err)
+	}
+	return nil
+}
+
+// checkUserName 检查 User=、Group=
+func checkUserName(field, value string) error {
+	if value != "" && !userNamePattern.MatchString(value) {
+		return fmt.Errorf("%w: %s %q is not a valid user or group name", ErrUnsafeUnitValue, field, value)
+	}
+	return nil
+}
+
+// checkUnitNames 检查 After=、Requires= 等引用的单元名
+func checkUnitNames(field string, names []string) error {
+	for _, name := range names {
+		if !unitNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: %s %q is not a valid unit name", ErrUnsafeUnitValue, field, name)
+		}
+	}
+	return nil
+}
+
+// quoteEnvironment 将环境变量渲染为 Environment= 的取值
+// 整个赋值放在双引号中，反斜杠和双引号按 C 风格转义，% 保留为说明符
+func quoteEnvironment(key, value string) (string, error) {
+	if !envNamePattern.MatchString(key) {
+		return "", fmt.Errorf("%w: environment variable name %q is invalid", ErrUnsafeUnitValue, key)
+	}
+	field := "environment variable " + key
+	// 取值在引号内，行尾的反斜杠转义后不会引起续行
+	if err := checkControl(field, value); err != nil {
+		return "", err
+	}
+	if err := checkSpecifiers(field, value); err != nil {
+		return "", err
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + key + "=" + replacer.Replace(value) + `"`, nil
+}
+
+// checkOption 检查附加配置项的小节、名称和取值
+// 是否展开说明符取决于具体配置项，这里只检查取值能否安全写成一行
+func checkOption(opt UnitOption) error {
+	if !sectionPattern.MatchString(opt.Section) {
+		return fmt.Errorf("%w: unknown section %q", ErrUnsafeUnitValue, opt.Section)
+	}
+	if !optionNamePattern.MatchString(opt.Name) {
+		return fmt.Errorf("%w: invalid option name %q", ErrUnsafeUnitValue, opt.Name)
+	}
+	return checkLineValue(opt.Section+"."+opt.Name, opt.Value)
+}
+
+// ValidateUnitContent 结构化校验单元文件或 drop-in 内容
+// 只允许已知小节和格式正确的配置项，requireExecStart 为 true 时要求 [Service] 中有 ExecStart=
+func ValidateUnitContent(content string, requireExecStart bool) error {
+	if !utf8.ValidString(content) {
+		return fmt.Errorf("%w: content is not valid UTF-8", ErrInvalidUnitFile)
+	}
+	for _, r := range content {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return fmt.Errorf("%w: content contains control character %q", ErrInvalidUnitFile, r)
+		}
+	}
+
+	options, err := ParseUnitFile(bytes.NewReader([]byte(content)))
+	if err != nil {
+		return err
+	}
+
+	hasExecStart := false
+	for _, opt := range options {
+		if err := checkOption(opt); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidUnitFile, err)
+		}
+		if opt.Section == "Service" && strings.HasPrefix(opt.Name, "Exec") && opt.Value != "" {
+			if _, err := SplitUnitWords(opt.Value); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidUnitFile, opt.Name, err)
+			}
+		}
+		if opt.Section == "Service" && opt.Name == "ExecStart" && opt.Value != "" {
+			hasExecStart = true
+		}
+	}
+
+	if requireExecStart && !hasExecStart {
+		return fmt.Errorf("%w: [Service] section must contain ExecStart=", ErrInvalidUnitFile)
+	}
+	return nil
+}
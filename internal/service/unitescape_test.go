@@ -0,0 +1,179 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQuoteEnvironment(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       string
+		wantErr    bool
+	}{
+		{key: "A", value: "1", want: `"A=1"`},
+		{key: "MSG", value: "hello world", want: `"MSG=hello world"`},
+		{key: "Q", value: `say "hi"`, want: `"Q=say \"hi\""`},
+		{key: "P", value: `C:\dir\`, want: `"P=C:\\dir\\"`},
+		{key: "PCT", value: "100%%", want: `"PCT=100%%"`},
+		{key: "HOST", value: "%H", want: `"HOST=%H"`},
+		{key: "EMPTY", value: "", want: `"EMPTY="`},
+		{key: "TAB", value: "a\tb", want: "\"TAB=a\tb\""},
+		{key: "1BAD", value: "x", wantErr: true},
+		{key: "A-B", value: "x", wantErr: true},
+		{key: "", value: "x", wantErr: true},
+		{key: "NL", value: "a\nb", wantErr: true},
+		{key: "PCT", value: "100%", wantErr: true},
+		{key: "SPEC", value: "%z", wantErr: true},
+		{key: "UTF", value: "\xff", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := quoteEnvironment(tt.key, tt.value)
+		if tt.wantErr {
+			if !errors.Is(err, ErrUnsafeUnitValue) {
+				t.Errorf("quoteEnvironment(%q, %q) = %q, %v, want ErrUnsafeUnitValue", tt.key, tt.value, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("quoteEnvironment(%q, %q) = %q, %v, want %q", tt.key, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestQuoteEnvironmentRoundTrip(t *testing.T) {
+	values := []string{"plain", "two words", `quote " inside`, `back\slash`, `trailing\`, "'single'"}
+	for _, value := range values {
+		quoted, err := quoteEnvironment("V", value)
+		if err != nil {
+			t.Fatalf("quoteEnvironment(%q): %v", value, err)
+		}
+		env, err := ParseEnvironment(quoted)
+		if err != nil {
+			t.Fatalf("ParseEnvironment(%q): %v", quoted, err)
+		}
+		if env["V"] != value {
+			t.Errorf("round trip of %q = %q", value, env["V"])
+		}
+	}
+}
+
+func TestCheckSpecifiers(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"no specifiers", true},
+		{"%n-%i", true},
+		{"50%%", true},
+		{"%%%h", true},
+		{"", true},
+		{"50%", false},
+		{"%z", false},
+		{"%%%", false},
+		{"a % b", false},
+	}
+	for _, tt := range tests {
+		err := checkSpecifiers("field", tt.value)
+		if ok := err == nil; ok != tt.ok {
+			t.Errorf("checkSpecifiers(%q) = %v, want ok %v", tt.value, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrUnsafeUnitValue) {
+			t.Errorf("checkSpecifiers(%q) = %v, want ErrUnsafeUnitValue", tt.value, err)
+		}
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"/usr/bin/app --port 8080", true},
+		{`/bin/sh -c "echo %%s"`, true},
+		{"/usr/bin/app\n[Service]", false},
+		{"/usr/bin/app \\", false},
+		{`/bin/sh -c "unterminated`, false},
+		{"/usr/bin/app 100%", false},
+	}
+	for _, tt := range tests {
+		err := checkCommand("ExecStart", tt.value)
+		if ok := err == nil; ok != tt.ok {
+			t.Errorf("checkCommand(%q) = %v, want ok %v", tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckOption(t *testing.T) {
+	tests := []struct {
+		opt UnitOption
+		ok  bool
+	}{
+		{UnitOption{Section: "Service", Name: "LimitNOFILE", Value: "65536"}, true},
+		{UnitOption{Section: "X-Custom", Name: "X-Owner", Value: "team"}, true},
+		{UnitOption{Section: "Service", Name: "Nice", Value: "50%"}, true},
+		{UnitOption{Section: "Bogus", Name: "Nice", Value: "1"}, false},
+		{UnitOption{Section: "Service", Name: "Bad Name", Value: "1"}, false},
+		{UnitOption{Section: "Service", Name: "Nice", Value: "1\n[Unit]"}, false},
+		{UnitOption{Section: "Service", Name: "Nice", Value: "1\\"}, false},
+	}
+	for _, tt := range tests {
+		err := checkOption(tt.opt)
+		if ok := err == nil; ok != tt.ok {
+			t.Errorf("checkOption(%+v) = %v, want ok %v", tt.opt, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateUnitContent(t *testing.T) {
+	tests := []struct {
+		name             string
+		content          string
+		requireExecStart bool
+		ok               bool
+	}{
+		{
+			name:             "full unit",
+			content:          "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\n\n[Install]\nWantedBy=multi-user.target\n",
+			requireExecStart: true,
+			ok:               true,
+		},
+		{
+			name:    "drop-in without ExecStart",
+			content: "[Service]\nLimitNOFILE=65536\n",
+			ok:      true,
+		},
+		{
+			name:    "drop-in resetting ExecStart",
+			content: "[Service]\nExecStart=\nExecStart=/usr/bin/other\n",
+			ok:      true,
+		},
+		{
+			name:             "missing ExecStart",
+			content:          "[Service]\nUser=app\n",
+			requireExecStart: true,
+		},
+		{
+			name:             "only empty ExecStart",
+			content:          "[Service]\nExecStart=\n",
+			requireExecStart: true,
+		},
+		{name: "unknown section", content: "[Bogus]\nA=1\n"},
+		{name: "invalid option name", content: "[Service]\nBad Name=1\n"},
+		{name: "unbalanced quotes", content: "[Service]\nExecStartPre=/bin/sh -c \"x\n"},
+		{name: "control character", content: "[Service]\nUser=a\x00b\n"},
+		{name: "invalid utf-8", content: "[Service]\nUser=\xff\n"},
+		{name: "assignment outside section", content: "User=app\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUnitContent(tt.content, tt.requireExecStart)
+			if ok := err == nil; ok != tt.ok {
+				t.Fatalf("err = %v, want ok %v", err, tt.ok)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidUnitFile) {
+				t.Fatalf("err = %v, want ErrInvalidUnitFile", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,543 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/hooks"
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/validator"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidUnitFile 单元文件语法错误
+var ErrInvalidUnitFile = errors.New("invalid unit file")
+
+// UnitOption 单元文件中的一条配置项
+type UnitOption = hooks.UnitOption
+
+// UnitConfig 从单元文件解析出的服务配置
+type UnitConfig struct {
+	Unit     string               `json:"unit"`
+	Sources  []string             `json:"sources"` // 基础单元文件及按应用顺序排列的 drop-in
+	Config   *hooks.ServiceConfig `json:"config"`
+	WantedBy []string             `json:"wanted_by,omitempty"`
+	Extra    []UnitOption         `json:"extra,omitempty"` // ServiceConfig 未建模的配置项，保持原有顺序
+}
+
+// ParseUnitFile 解析单元文件为配置项列表
+// 支持 # 和 ; 注释、行尾反斜杠续行，重复的配置项按出现顺序保留
+func ParseUnitFile(r io.Reader) ([]UnitOption, error) {
+	var (
+		options []UnitOption
+		section string
+		pending strings.Builder // 续行中的内容
+		lineNo  int
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		// 续行中的注释行会被 systemd 忽略
+		if line == "" && pending.Len() == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasSuffix(line, "\\") {
+			pending.WriteString(strings.TrimSuffix(line, "\\"))
+			pending.WriteString(" ")
+			continue
+		}
+		if pending.Len() > 0 {
+			pending.WriteString(line)
+			line = pending.String()
+			pending.Reset()
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || len(line) < 3 {
+				return nil, fmt.Errorf("%w: line %d: invalid section header %q", ErrInvalidUnitFile, lineNo, line)
+			}
+			section = line[1 : len(line)-1]
+			continue
+		}
+
+		if err := appendUnitOption(&options, section, line); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidUnitFile, lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read unit file: %w", err)
+	}
+
+	// 文件以反斜杠结尾时保留最后一行
+	if pending.Len() > 0 {
+		if err := appendUnitOption(&options, section, strings.TrimSpace(pending.String())); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidUnitFile, lineNo, err)
+		}
+	}
+
+	return options, nil
+}
+
+// appendUnitOption 解析 Key=Value 并追加到配置项列表
+func appendUnitOption(options *[]UnitOption, section, line string) error {
+	if section == "" {
+		return fmt.Errorf("assignment outside of section: %q", line)
+	}
+	name, value, ok := strings.Cut(line, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("missing '=': %q", line)
+	}
+	*options = append(*options, UnitOption{
+		Section: section,
+		Name:    name,
+		Value:   strings.TrimSpace(value),
+	})
+	return nil
+}
+
+// SplitUnitWords 按 systemd 规则拆分以空白分隔的值，支持单引号、双引号和反斜杠转义
+func SplitUnitWords(s string) ([]string, error) {
+	var (
+		words   []string
+		current strings.Builder
+		inWord  bool
+		quote   rune
+	)
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("trailing backslash in %q", s)
+			}
+			i++
+			current.WriteRune(unescapeUnitRune(runes[i]))
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case unicode.IsSpace(c):
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+// unescapeUnitRune 转换 C 风格转义字符
+func unescapeUnitRune(c rune) rune {
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	default:
+		return c
+	}
+}
+
+// ParseEnvironment 解析 Environment= 的值为环境变量
+func ParseEnvironment(value string) (map[string]string, error) {
+	words, err := SplitUnitWords(value)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(words))
+	for _, word := range words {
+		key, val, ok := strings.Cut(word, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid environment assignment %q", word)
+		}
+		env[key] = val
+	}
+	return env, nil
+}
+
+// ParseTimespan 解析 systemd 时间格式（如 "5"、"30s"、"1min 30s"），无单位时按秒计算
+func ParseTimespan(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"us": time.Microsecond, "usec": time.Microsecond,
+		"ms": time.Millisecond, "msec": time.Millisecond,
+		"s": time.Second, "sec": time.Second, "second": time.Second, "seconds": time.Second,
+		"m": time.Minute, "min": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+		"h": time.Hour, "hr": time.Hour, "hour": time.Hour, "hours": time.Hour,
+		"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+	}
+
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return 0, fmt.Errorf("empty timespan")
+	}
+
+	var total time.Duration
+	for s != "" {
+		end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+		if end == 0 {
+			return 0, fmt.Errorf("invalid timespan %q", value)
+		}
+		if end < 0 {
+			end = len(s)
+		}
+		number, err := strconv.ParseFloat(s[:end], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timespan %q", value)
+		}
+		s = strings.TrimLeft(s[end:], " ")
+
+		unitEnd := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+		if unitEnd < 0 {
+			unitEnd = len(s)
+		}
+		unit := time.Second
+		if unitEnd > 0 {
+			var ok bool
+			if unit, ok = units[s[:unitEnd]]; !ok {
+				return 0, fmt.Errorf("invalid timespan unit %q", s[:unitEnd])
+			}
+		}
+		total += time.Duration(number * float64(unit))
+		s = strings.TrimLeft(s[unitEnd:], " ")
+	}
+	return total, nil
+}
+
+// unitExecHooks 单元中映射为钩子的 Exec 配置项
+var unitExecHooks = map[string]hooks.HookType{
+	"ExecStartPre":  hooks.HookPreStart,
+	"ExecStartPost": hooks.HookPostStart,
+	"ExecStopPre":   hooks.HookPreStop,
+	"ExecStopPost":  hooks.HookPostStop,
+}
+
+// NewUnitConfig 将配置项转换为 ServiceConfig，未建模或无法解析的配置项放入 Extra
+// 按出现顺序应用，后出现的单值配置覆盖先前的值，列表配置的空赋值清空已有值
+func NewUnitConfig(unitName string, options []UnitOption) *UnitConfig {
+	config := &hooks.ServiceConfig{
+		ServiceName: strings.TrimSuffix(unitName, ".service"),
+		Type:        "simple",
+		Hooks:       []hooks.Hook{},
+	}
+	uc := &UnitConfig{Unit: unitName, Config: config}
+
+	appendList := func(list *[]string, value string) {
+		if value == "" {
+			*list = nil
+			return
+		}
+		*list = append(*list, strings.Fields(value)...)
+	}
+
+	for _, opt := range options {
+		if isMarkerOption(opt) {
+			continue
+		}
+
+		known := true
+		switch opt.Section + "." + opt.Name {
+		case "Unit.Description":
+			config.Description = opt.Value
+		case "Unit.After":
+			appendList(&config.After, opt.Value)
+		case "Unit.Before":
+			appendList(&config.Before, opt.Value)
+		case "Unit.Requires":
+			appendList(&config.Requires, opt.Value)
+		case "Unit.Wants":
+			appendList(&config.Wants, opt.Value)
+		case "Unit.StartLimitBurst", "Service.StartLimitBurst":
+			known = parseUnitInt(opt.Value, &config.StartLimitBurst)
+		case "Unit.StartLimitIntervalSec", "Service.StartLimitIntervalSec":
+			config.StartLimitIntervalSec = opt.Value
+		case "Service.Type":
+			config.Type = opt.Value
+		case "Service.PIDFile":
+			config.PIDFile = opt.Value
+		case "Service.RemainAfterExit":
+			known = parseUnitBool(opt.Value, &config.RemainAfterExit)
+		case "Service.User":
+			config.User = opt.Value
+		case "Service.Group":
+			config.Group = opt.Value
+		case "Service.UMask":
+			config.UMask = opt.Value
+		case "Service.Nice":
+			var nice int
+			if known = parseUnitInt(opt.Value, &nice); known {
+				config.Nice = &nice
+			}
+		case "Service.EnvironmentFile":
+			if opt.Value == "" {
+				config.EnvironmentFile = nil
+				break
+			}
+			config.EnvironmentFile = append(config.EnvironmentFile, opt.Value)
+		case "Service.ExecStop":
+			// 多条 ExecStop 仅保留第一条
+			switch {
+			case opt.Value == "":
+				config.ExecStop = ""
+			case config.ExecStop == "":
+				config.ExecStop = opt.Value
+			default:
+				known = false
+			}
+		case "Service.TimeoutStartSec":
+			config.TimeoutStartSec = opt.Value
+		case "Service.TimeoutStopSec":
+			config.TimeoutStopSec = opt.Value
+		case "Service.KillMode":
+			config.KillMode = opt.Value
+		case "Service.KillSignal":
+			config.KillSignal = opt.Value
+		case "Service.SuccessExitStatus":
+			appendList(&config.SuccessExitStatus, opt.Value)
+		case "Service.StandardOutput":
+			config.StandardOutput = opt.Value
+		case "Service.StandardError":
+			config.StandardError = opt.Value
+		case "Service.LimitNOFILE":
+			config.LimitNOFILE = opt.Value
+		case "Service.WorkingDirectory":
+			config.WorkingDirectory = opt.Value
+		case "Service.ExecStart":
+			// 空赋值清空命令，oneshot 服务可能有多条 ExecStart，仅保留第一条
+			switch {
+			case opt.Value == "":
+				config.ExecStart = ""
+			case config.ExecStart == "":
+				config.ExecStart = opt.Value
+			default:
+				known = false
+			}
+		case "Service.ExecReload":
+			config.ExecReload = opt.Value
+		case "Service.ExecStartPre", "Service.ExecStartPost", "Service.ExecStopPre", "Service.ExecStopPost":
+			hookType := unitExecHooks[opt.Name]
+			if opt.Value == "" {
+				config.Hooks = removeHooks(config.Hooks, hookType)
+				break
+			}
+			config.Hooks = append(config.Hooks, hooks.Hook{
+				Type:    hookType,
+				Name:    fmt.Sprintf("%s-%d", hookType, len(config.Hooks)+1),
+				Command: opt.Value,
+				Enabled: true,
+			})
+		case "Service.Environment":
+			if opt.Value == "" {
+				config.Environment = nil
+				break
+			}
+			env, err := ParseEnvironment(opt.Value)
+			if err != nil {
+				known = false
+				break
+			}
+			if config.Environment == nil {
+				config.Environment = make(map[string]string, len(env))
+			}
+			for k, v := range env {
+				config.Environment[k] = v
+			}
+		case "Service.Restart":
+			config.RestartPolicy = opt.Value
+		case "Service.RestartSec":
+			d, err := ParseTimespan(opt.Value)
+			if err != nil {
+				known = false
+				break
+			}
+			config.RestartDelaySec = d
+		case "Service.MemoryMax", "Service.MemoryLimit":
+			config.MemoryLimit = opt.Value
+		case "Service.CPUQuota":
+			config.CPUQuota = opt.Value
+		case "Service.TasksMax":
+			known = parseUnitInt(opt.Value, &config.TasksMax)
+		case "Install.WantedBy":
+			appendList(&uc.WantedBy, opt.Value)
+		default:
+			known = false
+		}
+
+		if !known {
+			uc.Extra = append(uc.Extra, opt)
+		}
+	}
+
+	// 第一个安装目标作为服务的安装目标，其余目标在改写时作为附加配置项保留
+	if len(uc.WantedBy) > 0 {
+		config.InstallTarget = uc.WantedBy[0]
+	}
+
+	return uc
+}
+
+// parseUnitInt 解析整数配置，失败时返回 false 以便放入 Extra
+func parseUnitInt(value string, dst *int) bool {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+	*dst = n
+	return true
+}
+
+// parseUnitBool 解析布尔配置，失败时返回 false 以便放入 Extra
+func parseUnitBool(value string, dst *bool) bool {
+	switch strings.ToLower(value) {
+	case "1", "yes", "y", "true", "t", "on":
+		*dst = true
+	case "0", "no", "n", "false", "f", "off":
+		*dst = false
+	default:
+		return false
+	}
+	return true
+}
+
+// removeHooks 移除指定类型的钩子
+func removeHooks(list []hooks.Hook, hookType hooks.HookType) []hooks.Hook {
+	kept := list[:0]
+	for _, hook := range list {
+		if hook.Type != hookType {
+			kept = append(kept, hook)
+		}
+	}
+	return kept
+}
+
+// readUnitOptions 按顺序解析多个单元文件
+func readUnitOptions(paths []string) ([]UnitOption, error) {
+	var options []UnitOption
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open unit file: %w", err)
+		}
+		opts, err := ParseUnitFile(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		options = append(options, opts...)
+	}
+	return options, nil
+}
+
+// GetServiceConfig 从单元文件及其 drop-in 解析服务的实际配置
+// 单元文件无法表达的钩子、通知和健康检查配置取自部署时记录的配置
+func (s *service) GetServiceConfig(ctx context.Context, serviceName string) (*UnitConfig, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "GetServiceConfig validation failed", "error", err, "service", serviceName)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	uc, err := s.loadUnitConfig(ctx, serviceName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if saved, err := s.workspaceMgr.LoadServiceConfig(serviceName); err == nil {
+		mergeSavedConfig(uc.Config, saved)
+	}
+
+	return uc, nil
+}
+
+// loadUnitConfig 解析 systemd 加载的单元文件，withDropIns 为 true 时按顺序叠加 drop-in
+func (s *service) loadUnitConfig(ctx context.Context, serviceName string, withDropIns bool) (*UnitConfig, error) {
+	unitName, sources, options, err := s.loadUnitOptions(ctx, serviceName, withDropIns)
+	if err != nil {
+		return nil, err
+	}
+
+	uc := NewUnitConfig(unitName, options)
+	uc.Sources = sources
+	return uc, nil
+}
+
+// loadUnitOptions 读取 systemd 加载的单元文件的配置项，返回单元名和按顺序读取的文件
+func (s *service) loadUnitOptions(ctx context.Context, serviceName string, withDropIns bool) (string, []string, []UnitOption, error) {
+	unit, err := s.systemdMgr.Load(ctx, serviceName)
+	if err != nil {
+		logger.Error(ctx, "Failed to load unit", "error", err, "service", serviceName)
+		return "", nil, nil, fmt.Errorf("failed to load unit: %w", err)
+	}
+
+	sources := []string{}
+	if unit.FragmentPath != "" {
+		sources = append(sources, unit.FragmentPath)
+	}
+	if withDropIns {
+		sources = append(sources, unit.DropInPaths...)
+	}
+
+	options, err := readUnitOptions(sources)
+	if err != nil {
+		logger.Error(ctx, "Failed to parse unit file", "error", err, "service", serviceName)
+		return "", nil, nil, fmt.Errorf("failed to parse unit file: %w", err)
+	}
+	return unit.Name, sources, options, nil
+}
+
+// mergeSavedConfig 补充单元文件中不存在的配置
+func mergeSavedConfig(config, saved *hooks.ServiceConfig) {
+	config.Notifications = saved.Notifications
+	config.HealthCheck = saved.HealthCheck
+	for _, hook := range saved.Hooks {
+		// 已渲染为 Exec 配置项的钩子以单元文件为准
+		if hook.Command != "" && isExecHook(hook.Type) {
+			continue
+		}
+		config.Hooks = append(config.Hooks, hook)
+	}
+}
+
+// isExecHook 判断钩子类型是否会渲染为单元的 Exec 配置项
+func isExecHook(hookType hooks.HookType) bool {
+	for _, t := range unitExecHooks {
+		if t == hookType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseUnitFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []UnitOption
+		wantErr bool
+	}{
+		{
+			name:    "sections and comments",
+			content: "# comment\n[Unit]\nDescription=My App\n; other comment\n\n[Service]\nExecStart=/usr/bin/app --flag\n",
+			want: []UnitOption{
+				{Section: "Unit", Name: "Description", Value: "My App"},
+				{Section: "Service", Name: "ExecStart", Value: "/usr/bin/app --flag"},
+			},
+		},
+		{
+			name:    "duplicate options keep order",
+			content: "[Service]\nEnvironment=A=1\nEnvironment=B=2\n",
+			want: []UnitOption{
+				{Section: "Service", Name: "Environment", Value: "A=1"},
+				{Section: "Service", Name: "Environment", Value: "B=2"},
+			},
+		},
+		{
+			name:    "continuation lines",
+			content: "[Service]\nExecStart=/usr/bin/app \\\n  --a \\\n  --b\n",
+			want: []UnitOption{
+				{Section: "Service", Name: "ExecStart", Value: "/usr/bin/app  --a  --b"},
+			},
+		},
+		{
+			name:    "trailing backslash at end of file",
+			content: "[Service]\nExecStart=/usr/bin/app \\",
+			want: []UnitOption{
+				{Section: "Service", Name: "ExecStart", Value: "/usr/bin/app"},
+			},
+		},
+		{
+			name:    "whitespace around key and value",
+			content: "[Service]\n  User =  app  \n",
+			want: []UnitOption{
+				{Section: "Service", Name: "User", Value: "app"},
+			},
+		},
+		{name: "assignment outside section", content: "Description=x\n", wantErr: true},
+		{name: "missing equals", content: "[Unit]\nDescription\n", wantErr: true},
+		{name: "empty key", content: "[Unit]\n=x\n", wantErr: true},
+		{name: "unterminated section", content: "[Unit\n", wantErr: true},
+		{name: "empty section", content: "[]\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUnitFile(strings.NewReader(tt.content))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidUnitFile) {
+					t.Fatalf("err = %v, want ErrInvalidUnitFile", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitUnitWords(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "  a  b\tc ", want: []string{"a", "b", "c"}},
+		{in: `"hello world" x`, want: []string{"hello world", "x"}},
+		{in: `'single "quoted"'`, want: []string{`single "quoted"`}},
+		{in: `a\ b`, want: []string{"a b"}},
+		{in: `line\nbreak`, want: []string{"line\nbreak"}},
+		{in: `pre"mid"post`, want: []string{"premidpost"}},
+		{in: `""`, want: []string{""}},
+		{in: `"unterminated`, wantErr: true},
+		{in: `trailing\`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SplitUnitWords(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SplitUnitWords(%q) = %q, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitUnitWords(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitUnitWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseEnvironment(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{in: "A=1 B=2", want: map[string]string{"A": "1", "B": "2"}},
+		{in: `"MSG=hello world" EMPTY=`, want: map[string]string{"MSG": "hello world", "EMPTY": ""}},
+		{in: "URL=http://x/?a=b", want: map[string]string{"URL": "http://x/?a=b"}},
+		{in: "", want: map[string]string{}},
+		{in: "NOEQUALS", wantErr: true},
+		{in: "=value", wantErr: true},
+		{in: `"A=1`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseEnvironment(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseEnvironment(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseEnvironment(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseEnvironment(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimespan(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "5", want: 5 * time.Second},
+		{in: "30s", want: 30 * time.Second},
+		{in: "1min 30s", want: 90 * time.Second},
+		{in: "1min30s", want: 90 * time.Second},
+		{in: "500ms", want: 500 * time.Millisecond},
+		{in: "1.5h", want: 90 * time.Minute},
+		{in: "2 days", want: 48 * time.Hour},
+		{in: "1w", want: 7 * 24 * time.Hour},
+		{in: " 10 sec ", want: 10 * time.Second},
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "5 fortnights", wantErr: true},
+		{in: "1..2s", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimespan(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTimespan(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTimespan(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTimespan(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
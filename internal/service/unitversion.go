@@ -0,0 +1,223 @@
+package service
+
+import (
+	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/unitstore"
+	"api-systemd/internal/pkg/validator"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidVersionFile 历史版本查询的文件不属于该服务
+var ErrInvalidVersionFile = errors.New("file must be the service unit, its timer or one of its drop-ins")
+
+// UnitVersion 单元文件历史版本及内容
+type UnitVersion struct {
+	unitstore.Version
+	File    string `json:"file"`
+	Content string `json:"content"`
+}
+
+// versionFile 校验并返回服务在单元目录下的相对文件名，为空时默认为服务单元文件
+// 只允许 <name>.service、模板 <name>@.service、<name>.timer、<name>.socket 及单元的 drop-in（<unit>.d/*.conf）
+func versionFile(serviceName, file string) (string, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		return "", fmt.Errorf("validation failed: %w", err)
+	}
+
+	unitName := systemd.UnitName(serviceName)
+	if file == "" {
+		return unitName, nil
+	}
+	if file == unitName || file == templateUnit(serviceName) || file == timerUnitName(serviceName) || file == socketUnitName(serviceName) {
+		return file, nil
+	}
+	if dir, name, ok := strings.Cut(file, "/"); ok && (dir == unitName+".d" || dir == templateUnit(serviceName)+".d") {
+		if err := validator.ValidateDropInName(name); err == nil {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidVersionFile, file)
+}
+
+// WriteUnitFile 原子写入原始单元文件内容并重新加载 systemd，返回单元文件路径
+// 不覆盖不带标记的同名单元，内容不带标记时通过标记 drop-in 记录所有权
+func (s *service) WriteUnitFile(ctx context.Context, serviceName, content string) (string, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "WriteUnitFile validation failed", "error", err, "service", serviceName)
+		return "", fmt.Errorf("validation failed: %w", err)
+	}
+	// 原始单元内容同样需要通过结构校验
+	if err := ValidateUnitContent(content, true); err != nil {
+		logger.Error(ctx, "WriteUnitFile validation failed", "error", err, "service", serviceName)
+		return "", err
+	}
+	unitName := systemd.UnitName(serviceName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkDeployTarget(ctx, unitName); err != nil {
+		return "", err
+	}
+
+	path := s.paths.Service(serviceName)
+	logger.Info(ctx, "Creating config file", "service", serviceName, "file", path)
+	if err := s.units.WriteFile(path, []byte(content), 0644); err != nil {
+		logger.Error(ctx, "Failed to write config file", "error", err, "service", serviceName, "file", path)
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	if !containsManagedMarker(strings.NewReader(content)) {
+		if err := s.writeManagedDropIn(unitName); err != nil {
+			logger.Error(ctx, "Failed to write managed drop-in", "error", err, "service", serviceName)
+			return "", fmt.Errorf("failed to write managed drop-in: %w", err)
+		}
+	}
+
+	logger.Info(ctx, "Reloading systemd daemon")
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return "", fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+	return path, nil
+}
+
+// RemoveUnitFile 删除受管单元文件及其标记 drop-in 并重新加载 systemd，删除前的内容保留在历史版本中
+func (s *service) RemoveUnitFile(ctx context.Context, serviceName string) (string, error) {
+	if err := validator.ValidateServiceName(serviceName); err != nil {
+		logger.Error(ctx, "RemoveUnitFile validation failed", "error", err, "service", serviceName)
+		return "", fmt.Errorf("validation failed: %w", err)
+	}
+	unitName := systemd.UnitName(serviceName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkUnitOperation(ctx, unitName, policy.OpRemove); err != nil {
+		return "", err
+	}
+
+	path := s.paths.Service(serviceName)
+	logger.Info(ctx, "Deleting config file", "service", serviceName, "file", path)
+	if err := s.units.Remove(path); err != nil {
+		logger.Error(ctx, "Failed to delete config file", "error", err, "service", serviceName, "file", path)
+		return "", fmt.Errorf("failed to delete config file: %w", err)
+	}
+	// 同名单元之后可能由他人手工编写，不再保留所有权标记
+	dropIn := filepath.Join(s.paths.DropInDir(unitName), managedDropIn)
+	if err := s.units.Remove(dropIn); err != nil && !os.IsNotExist(err) {
+		logger.Warn(ctx, "Failed to remove managed drop-in", "error", err, "file", dropIn)
+	}
+	os.Remove(s.paths.DropInDir(unitName))
+
+	logger.Info(ctx, "Reloading systemd daemon")
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return "", fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+	return path, nil
+}
+
+// ListUnitVersions 列出单元文件保留的历史版本
+func (s *service) ListUnitVersions(ctx context.Context, serviceName, file string) ([]unitstore.Version, error) {
+	name, err := versionFile(serviceName, file)
+	if err != nil {
+		logger.Error(ctx, "ListUnitVersions validation failed", "error", err, "service", serviceName, "file", file)
+		return nil, err
+	}
+
+	versions, err := s.units.Versions(name)
+	if err != nil {
+		logger.Error(ctx, "Failed to list unit versions", "error", err, "file", name)
+		return nil, err
+	}
+	if versions == nil {
+		versions = []unitstore.Version{}
+	}
+	return versions, nil
+}
+
+// GetUnitVersion 读取单元文件的一个历史版本
+func (s *service) GetUnitVersion(ctx context.Context, serviceName, file string, id int) (*UnitVersion, error) {
+	name, err := versionFile(serviceName, file)
+	if err != nil {
+		logger.Error(ctx, "GetUnitVersion validation failed", "error", err, "service", serviceName, "file", file)
+		return nil, err
+	}
+
+	versions, err := s.units.Versions(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.ID != id {
+			continue
+		}
+		content, err := s.units.Read(name, id)
+		if err != nil {
+			return nil, err
+		}
+		return &UnitVersion{Version: v, File: name, Content: string(content)}, nil
+	}
+	return nil, fmt.Errorf("%w: %s version %d", unitstore.ErrVersionNotFound, name, id)
+}
+
+// DiffUnitVersions 比较单元文件的两个历史版本，to 为 0 时与当前文件比较
+func (s *service) DiffUnitVersions(ctx context.Context, serviceName, file string, from, to int) (string, error) {
+	name, err := versionFile(serviceName, file)
+	if err != nil {
+		logger.Error(ctx, "DiffUnitVersions validation failed", "error", err, "service", serviceName, "file", file)
+		return "", err
+	}
+	return s.units.Diff(name, from, to)
+}
+
+// RestoreUnitVersion 将单元文件恢复到历史版本并重新加载 systemd
+func (s *service) RestoreUnitVersion(ctx context.Context, serviceName, file string, id int) error {
+	name, err := versionFile(serviceName, file)
+	if err != nil {
+		logger.Error(ctx, "RestoreUnitVersion validation failed", "error", err, "service", serviceName, "file", file)
+		return err
+	}
+	unitName := systemd.UnitName(serviceName)
+	if err := s.authorize(ctx, unitName, policy.OpRestore); err != nil {
+		return err
+	}
+
+	content, err := s.units.Read(name, id)
+	if err != nil {
+		return err
+	}
+	// 单元已被删除时，以历史版本中的所有权标记判断是否由本系统管理
+	if !s.allowUnmanaged && !s.isManagedUnit(unitName) && !containsManagedMarker(bytes.NewReader(content)) {
+		logger.Warn(ctx, "Refusing to restore unmanaged unit", "unit", unitName, "file", name)
+		return fmt.Errorf("%w: %s", ErrUnmanaged, unitName)
+	}
+	// 历史版本可能早于当前校验规则，写回前重新校验
+	if err := ValidateUnitContent(string(content), filepath.Ext(name) == ".service"); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logger.Info(ctx, "Restoring unit version", "service", serviceName, "file", name, "version", id)
+	if err := s.units.Restore(name, id); err != nil {
+		logger.Error(ctx, "Failed to restore unit version", "error", err, "file", name, "version", id)
+		return fmt.Errorf("failed to restore unit version: %w", err)
+	}
+
+	logger.Info(ctx, "Reloading systemd daemon")
+	if err := s.systemdMgr.ReloadDaemon(ctx); err != nil {
+		logger.Error(ctx, "Failed to reload systemd daemon", "error", err)
+		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+	}
+	return nil
+}
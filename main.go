@@ -2,7 +2,11 @@ package main
 
 import (
 	"api-systemd/internal/pkg/config"
+	"api-systemd/internal/pkg/events"
 	"api-systemd/internal/pkg/logger"
+	"api-systemd/internal/pkg/policy"
+	"api-systemd/internal/pkg/systemd"
+	"api-systemd/internal/pkg/unitpath"
 	"api-systemd/internal/router"
 	"context"
 	"flag"
@@ -30,10 +34,28 @@ func main() {
 	}
 
 	ctx := context.Background()
-	logger.Info(ctx, "Starting API-Systemd server", "port", serverPort, "api_key_configured", cfg.Security.APIKey != "")
+	logger.Info(ctx, "Starting API-Systemd server", "port", serverPort, "api_key_configured", cfg.Security.APIKey != "", "user_mode", cfg.Systemd.UserMode)
+
+	// 加载单元操作策略
+	pol, err := policy.Load(cfg.Systemd.PolicyFile)
+	if err != nil {
+		logger.Error(ctx, "Failed to load policy", "error", err, "file", cfg.Systemd.PolicyFile)
+		panic(err)
+	}
+
+	// 创建 systemd D-Bus 连接管理器
+	systemdMgr := systemd.NewManager(cfg.Systemd.UserMode)
+	defer systemdMgr.Close()
+
+	// 单元文件路径解析
+	paths := unitpath.New(cfg.Systemd.UnitDir, cfg.Systemd.UserMode)
+	logger.Info(ctx, "Using unit directory", "dir", paths.Dir(), "runtime", paths.Runtime())
+
+	// 创建事件代理
+	broker := events.NewBroker(events.DefaultBufferSize)
 
 	// 创建路由器
-	r := router.New(cfg)
+	r := router.New(cfg, systemdMgr, paths, broker, pol)
 
 	// 创建HTTP服务器
 	server := &http.Server{